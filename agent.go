@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gpgAgent is a client for the Assuan protocol spoken on gpg-agent's socket
+type gpgAgent struct {
+	socket string
+}
+
+// agentSocket finds the socket gpg-agent listens on
+func agentSocket() string {
+	out, err := exec.Command("gpgconf", "--list-dirs", "agent-socket").Output()
+	if err == nil && len(out) > 0 {
+		return strings.TrimSpace(string(out))
+	}
+	return filepath.Join(gnupgHome(), "S.gpg-agent")
+}
+
+func newGPGAgent(socket string) *gpgAgent {
+	return &gpgAgent{socket: socket}
+}
+
+// transact connects to the agent, sends a single command and calls
+// status for every status line in the response
+func (a *gpgAgent) transact(cmd string, status func(keyword, args string)) error {
+	conn, err := net.Dial("unix", a.socket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// The agent greets us with an OK line
+	if err := readResponse(r, conn, nil); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "%s\n", cmd); err != nil {
+		return err
+	}
+	return readResponse(r, conn, status)
+}
+
+// readResponse reads lines from the agent until it is done with a command
+func readResponse(r *bufio.Reader, conn net.Conn, status func(keyword, args string)) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "OK" || strings.HasPrefix(line, "OK "):
+			return nil
+		case strings.HasPrefix(line, "ERR "):
+			return agentError(line)
+		case strings.HasPrefix(line, "S "):
+			parts := strings.SplitN(line[2:], " ", 2)
+			if len(parts) == 1 {
+				parts = append(parts, "")
+			}
+			if status != nil {
+				status(parts[0], parts[1])
+			}
+		case strings.HasPrefix(line, "INQUIRE "):
+			// We have nothing to tell the agent
+			if _, err := fmt.Fprint(conn, "CAN\n"); err != nil {
+				return err
+			}
+		}
+		// Ignore data and comment lines
+	}
+}
+
+// agentError makes an error from an ERR line, which looks like
+// ERR <code> <description>
+func agentError(line string) error {
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) < 3 {
+		return errors.New(line)
+	}
+	return fmt.Errorf("gpg-agent: %s", parts[2])
+}
+
+// KeyInfo asks the agent about the secret key with the given keygrip
+func (a *gpgAgent) KeyInfo(keygrip string) (GPGAgentKeyInfo, error) {
+	var ki GPGAgentKeyInfo
+	err := a.transact("KEYINFO "+keygrip, func(keyword, args string) {
+		if keyword == "KEYINFO" {
+			ki = parseKeyinfo(args)
+		}
+	})
+	return ki, err
+}
+
+// ClearPassphrase makes the agent forget the cached passphrase for a key
+func (a *gpgAgent) ClearPassphrase(keygrip string) error {
+	return a.transact("CLEAR_PASSPHRASE --mode=normal "+keygrip, nil)
+}
+
+// ReloadAgent makes the agent forget all cached passphrases
+func (a *gpgAgent) ReloadAgent() error {
+	return a.transact("RELOADAGENT", nil)
+}
+
+// agentCache keeps track of when keys were used, to estimate how long
+// gpg-agent will keep their passphrases cached. The agent can't tell us,
+// but every use extends the time by default-cache-ttl, up to max-cache-ttl
+// after the passphrase was entered.
+type agentCache struct {
+	mu         sync.Mutex
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	first      map[string]time.Time
+	last       map[string]time.Time
+}
+
+// newAgentCache reads the cache TTLs from gpg-agent.conf
+func newAgentCache() *agentCache {
+	c := &agentCache{
+		defaultTTL: 600 * time.Second,
+		maxTTL:     7200 * time.Second,
+		first:      make(map[string]time.Time),
+		last:       make(map[string]time.Time),
+	}
+	f, err := os.Open(filepath.Join(gnupgHome(), "gpg-agent.conf"))
+	if err != nil {
+		return c
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		seconds, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "default-cache-ttl":
+			c.defaultTTL = time.Duration(seconds) * time.Second
+		case "max-cache-ttl":
+			c.maxTTL = time.Duration(seconds) * time.Second
+		}
+	}
+	return c
+}
+
+// used records that the key with the given keygrip was just used
+func (c *agentCache) used(keygrip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.first[keygrip]; !ok {
+		c.first[keygrip] = now
+	}
+	c.last[keygrip] = now
+}
+
+// forget records that the key is no longer cached
+func (c *agentCache) forget(keygrip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.first, keygrip)
+	delete(c.last, keygrip)
+}
+
+// forgetAll records that no keys are cached anymore
+func (c *agentCache) forgetAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.first = make(map[string]time.Time)
+	c.last = make(map[string]time.Time)
+}
+
+// remaining estimates how long the key stays cached, or 0 if unknown
+func (c *agentCache) remaining(keygrip string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last, ok := c.last[keygrip]
+	if !ok {
+		return 0
+	}
+	ttl := c.defaultTTL - time.Since(last)
+	if max := c.maxTTL - time.Since(c.first[keygrip]); max < ttl {
+		ttl = max
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testKeygrip = "22DC9A3A0AFA6C9FCDEB8C91AC03FAC85C1D1062"
+
+// fakeAgent speaks just enough Assuan to stand in for gpg-agent, and
+// records the commands it gets
+type fakeAgent struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, *gpgAgent) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "S.gpg-agent")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	fa := &fakeAgent{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go fa.serve(conn)
+		}
+	}()
+	return fa, newGPGAgent(socket)
+}
+
+func (fa *fakeAgent) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "# Home: /nowhere\nOK Pleased to meet you, process 1\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSuffix(line, "\n")
+		fa.mu.Lock()
+		fa.commands = append(fa.commands, cmd)
+		fa.mu.Unlock()
+		fields := strings.Fields(cmd)
+		switch {
+		case cmd == "KEYINFO "+testKeygrip:
+			fmt.Fprintf(conn, "S KEYINFO %s D - - 1 P - - -\nOK\n", testKeygrip)
+		case fields[0] == "KEYINFO":
+			fmt.Fprint(conn, "ERR 67108881 No secret key <GPG Agent>\n")
+		case fields[0] == "GET_PASSPHRASE":
+			fmt.Fprint(conn, "INQUIRE PINENTRY_LAUNCHED 1234 curses 1.2.1 - - -\n")
+		case cmd == "CAN":
+			fmt.Fprint(conn, "ERR 83886179 Operation cancelled <GPG Agent>\n")
+		default:
+			fmt.Fprint(conn, "OK\n")
+		}
+	}
+}
+
+func (fa *fakeAgent) received() []string {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return append([]string(nil), fa.commands...)
+}
+
+func TestAgentKeyInfo(t *testing.T) {
+	_, agent := newFakeAgent(t)
+	ki, err := agent.KeyInfo(testKeygrip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ki.KeyGrip != testKeygrip || ki.Type != "D" || !ki.Cached || ki.Protection != "P" {
+		t.Errorf("got %+v", ki)
+	}
+
+	ki, err = agent.KeyInfo("0000000000000000000000000000000000000000")
+	if err == nil || err.Error() != "gpg-agent: No secret key <GPG Agent>" {
+		t.Errorf("got error %v", err)
+	}
+	if ki.KeyGrip != "" {
+		t.Errorf("got %+v for a key the agent doesn't have", ki)
+	}
+}
+
+func TestAgentInquire(t *testing.T) {
+	fa, agent := newFakeAgent(t)
+	// The agent asks for more, which we cancel
+	err := agent.transact("GET_PASSPHRASE --data X", nil)
+	if err == nil || !strings.Contains(err.Error(), "Operation cancelled") {
+		t.Errorf("got error %v", err)
+	}
+	got := fa.received()
+	if len(got) != 2 || got[1] != "CAN" {
+		t.Errorf("agent got %q, want the command and CAN", got)
+	}
+}
+
+func TestAgentLock(t *testing.T) {
+	fa, agent := newFakeAgent(t)
+	if err := agent.ClearPassphrase(testKeygrip); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.ReloadAgent(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CLEAR_PASSPHRASE --mode=normal " + testKeygrip, "RELOADAGENT"}
+	if got := fa.received(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("agent got %q, want %q", got, want)
+	}
+}
+
+func TestAgentError(t *testing.T) {
+	for line, want := range map[string]string{
+		"ERR 67108881 No secret key <GPG Agent>": "gpg-agent: No secret key <GPG Agent>",
+		"ERR 1":                                  "ERR 1",
+	} {
+		if got := agentError(line).Error(); got != want {
+			t.Errorf("agentError(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
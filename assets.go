@@ -0,0 +1,202 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/limetext/qml-go"
+	"github.com/rjeczalik/notify"
+)
+
+// embeddedAssets are the assets GoPass is built with, which customized
+// assets fall back to
+//
+//go:embed assets
+var embeddedAssets embed.FS
+
+// The built in assets are loaded from qrc:/assets/
+func init() {
+	var rp qml.ResourcesPacker
+	err := fs.WalkDir(embeddedAssets, "assets", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := embeddedAssets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rp.Add(path, data)
+		return nil
+	})
+	if err != nil {
+		panic("cannot pack the built in assets: " + err.Error())
+	}
+	qml.LoadResources(rp.Pack())
+}
+
+// assetsDir is a directory of QML and SVG files to use instead of the ones
+// GoPass is built with, set with GOPASS_ASSETS. Files that aren't in it
+// are taken from the built in assets.
+func assetsDir() string {
+	return os.Getenv("GOPASS_ASSETS")
+}
+
+// mergeAssets copies the built in assets to a new temporary directory,
+// with the files in dir replacing them or added to them
+func mergeAssets(dir string) (string, error) {
+	merged, err := ioutil.TempDir("", "gopass-assets")
+	if err != nil {
+		return "", err
+	}
+	err = fs.WalkDir(embeddedAssets, "assets", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := embeddedAssets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writeAsset(merged, strings.TrimPrefix(path, "assets/"), data)
+	})
+	if err == nil {
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return writeAsset(merged, filepath.ToSlash(rel), data)
+		})
+	}
+	if err != nil {
+		os.RemoveAll(merged)
+		return "", err
+	}
+	return merged, nil
+}
+
+func writeAsset(dir, name string, data []byte) error {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// newEngine creates a QML engine that knows the models
+func newEngine() *qml.Engine {
+	engine := qml.NewEngine()
+	engine.Context().SetVar("passwords", &passwords)
+	engine.Context().SetVar("ui", &ui)
+	engine.Context().SetVar("revealed", &revealed)
+	engine.Context().SetVar("facets", &facets)
+	engine.Context().SetVar("shortcuts", &shortcuts)
+	engine.Context().SetVar("theme", &theme)
+	return engine
+}
+
+// loadWindow creates the main window from the assets at prefix, which is
+// either the built in qrc:/assets/ or a directory
+func loadWindow(prefix string) (*qml.Engine, *qml.Window, error) {
+	engine := newEngine()
+	if _, err := engine.LoadFile(prefix + "RoundButton.qml"); err != nil {
+		engine.Destroy()
+		return nil, nil, err
+	}
+	controls, err := engine.LoadFile(prefix + "main.qml")
+	if err != nil {
+		engine.Destroy()
+		return nil, nil, err
+	}
+	return engine, controls.CreateWindow(nil), nil
+}
+
+// customAssets keeps track of the assets loaded from assetsDir, so they
+// can be reloaded when they change
+type customAssets struct {
+	dir    string
+	merged string
+	engine *qml.Engine
+}
+
+// load merges the custom assets with the built in ones, and returns the
+// prefix to load them from
+func (a *customAssets) load() (string, error) {
+	merged, err := mergeAssets(a.dir)
+	if err != nil {
+		return "", err
+	}
+	a.merged = merged
+	return merged + string(filepath.Separator), nil
+}
+
+// reload replaces the window with one made from the changed assets. The old
+// window is kept when the new one can't be loaded.
+func (a *customAssets) reload() {
+	old := a.merged
+	prefix, err := a.load()
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Couldn't reload %s: %v", a.dir, err))
+		return
+	}
+	engine, w, err := loadWindow(prefix)
+	if err != nil {
+		os.RemoveAll(a.merged)
+		a.merged = old
+		ui.setStatus(fmt.Sprintf("Couldn't reload %s: %v", a.dir, err))
+		return
+	}
+	os.RemoveAll(old)
+
+	previous, visible := window, window.Bool("visible")
+	window = w
+	if visible {
+		window.Show()
+	}
+	previous.Destroy()
+	if a.engine != nil {
+		a.engine.Destroy()
+	}
+	a.engine = engine
+	passwords.Update("Reloaded " + a.dir)
+}
+
+// watch reloads the assets whenever a file in the directory changes
+func (a *customAssets) watch() {
+	c := make(chan notify.EventInfo, 1)
+	if err := notify.Watch(a.dir+"/...", c, notify.All); err != nil {
+		fmt.Fprintln(os.Stderr, "Not reloading assets:", err)
+		return
+	}
+	var timer *time.Timer
+	for range c {
+		// Editors save files in several steps, so wait until they're done
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(200*time.Millisecond, func() {
+			qml.RunMain(a.reload)
+		})
+	}
+}
+
+// close removes the merged assets, if there are any
+func (a *customAssets) close() {
+	if a != nil && a.merged != "" {
+		os.RemoveAll(a.merged)
+	}
+}
+
+var custom *customAssets
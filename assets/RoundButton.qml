@@ -7,14 +7,14 @@ Rectangle {
     color:"transparent"
     radius: 10
     property string label: "label"
-    property var btnColor: "#999"
+    property var btnColor: theme.button
     signal clicked()
     MouseArea {
         anchors.fill: parent
         onClicked: container.clicked()
         hoverEnabled: true
         onEntered: {
-           parent.color="#666" 
+           parent.color=theme.control
         }
         onExited: {
            parent.color="transparent" 
@@ -0,0 +1,25 @@
+import QtQuick 2.7
+import Qt.labs.platform 1.0
+
+SystemTrayIcon {
+    visible: true
+    iconSource: "logo.svg"
+    tooltip: "GoPass"
+
+    onActivated: ui.activate()
+
+    menu: Menu {
+        MenuItem {
+            text: "Show"
+            onTriggered: ui.activate()
+        }
+        MenuItem {
+            text: "Lock now"
+            onTriggered: ui.lock()
+        }
+        MenuItem {
+            text: "Quit"
+            onTriggered: ui.exit()
+        }
+    }
+}
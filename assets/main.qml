@@ -3,13 +3,13 @@ import QtQuick.Controls 1.3
 import QtQuick.Layouts 1.2
 import QtQuick.Controls.Styles 1.4
 import QtQuick.Window 2.2
+import QtQml 2.2
 
 ApplicationWindow {
     id: rootWindow
 
     property int margin: 10
 
-    visible: true
     title: "GoPass"
     width: 800
     height: 400
@@ -18,6 +18,58 @@ ApplicationWindow {
     flags: Qt.FramelessWindowHint | Qt.Window
     color: "transparent"
 
+    property bool showHelp: false
+
+    // Does what a key is bound to, see actions in keys.go
+    function runAction(action) {
+        var page = Math.max(1, Math.floor(hitList.height / (hitList.currentItem ? hitList.currentItem.height : 24)))
+        switch (action) {
+        case "copy-password": passwords.copyToClipboard(hitList.currentIndex); break
+        case "copy-user": passwords.copyUser(hitList.currentIndex); break
+        case "copy-otp": passwords.copyOTP(hitList.currentIndex); break
+        case "toggle-metadata": ui.toggleShowMetadata(); break
+        case "reveal":
+            if (ui.showMetadata) {
+                revealed.toggleAll()
+            } else {
+                ui.reveal()
+            }
+            break
+        case "edit": passwords.edit(hitList.currentIndex); break
+        case "favourite": passwords.toggleFavourite(hitList.currentIndex); break
+        case "next": hitList.incrementCurrentIndex(); break
+        case "prev": hitList.decrementCurrentIndex(); break
+        case "page-down": hitList.currentIndex = Math.min(hitList.count - 1, hitList.currentIndex + page); break
+        case "page-up": hitList.currentIndex = Math.max(0, hitList.currentIndex - page); break
+        case "enter-folder": passwords.enterSelected(); break
+        case "up-folder": passwords.up(); break
+        case "search": searchInput.selectAll(); searchInput.focus = true; break
+        case "audit": ui.audit(); break
+        case "lock": ui.lock(); break
+        case "help": showHelp = !showHelp; break
+        case "quit":
+            if (ui.askingPassphrase) {
+                ui.cancelPassphrase()
+            } else if (showHelp) {
+                showHelp = false
+            } else if (ui.showAudit) {
+                ui.closeAudit()
+            } else {
+                ui.quit()
+            }
+            break
+        }
+    }
+
+    // Called when another gopass is started, to bring this one to the front
+    function activate() {
+        searchInput.text = ""
+        show()
+        raise()
+        requestActivate()
+        searchInput.forceActiveFocus()
+    }
+
     MouseArea {
         id: mouseRegion
         property variant clickPos: "1,1"
@@ -42,7 +94,7 @@ ApplicationWindow {
     Rectangle {
         id: mainLayout
 
-        color: "#333"
+        color: theme.background
         radius: 10
         anchors.rightMargin: 0
         anchors.bottomMargin: 0
@@ -50,7 +102,7 @@ ApplicationWindow {
         anchors.topMargin: 0
         anchors.fill: parent
         border.width: 2
-        border.color: "#aaa"
+        border.color: theme.border
 
         RowLayout {
             id: panes
@@ -58,6 +110,45 @@ ApplicationWindow {
             anchors.fill: parent
             anchors.margins: 8
 
+            // Tags used in the store, click one to search for it
+            ColumnLayout {
+                id: tagPane
+                Layout.fillHeight: true
+                Layout.preferredWidth: 120
+                Layout.maximumWidth: 120
+                visible: facets.len > 0
+
+                Text {
+                    font.pixelSize: 14
+                    color: theme.dim
+                    text: "Tags"
+                }
+
+                ListView {
+                    Layout.fillHeight: true
+                    Layout.fillWidth: true
+                    clip: true
+                    model: facets.len
+                    delegate: Text {
+                        // Fetched again whenever the tags change
+                        property var tag: { facets.revision; return facets.get(index) }
+
+                        width: 120
+                        font.pixelSize: 14
+                        elide: Text.ElideRight
+                        color: searchInput.text === "tag:" + tag.name ? theme.accent : theme.folder
+                        text: tag.name + " (" + tag.count + ")"
+                        MouseArea {
+                            anchors.fill: parent
+                            onClicked: {
+                                searchInput.text = "tag:" + tag.name
+                                searchInput.forceActiveFocus()
+                            }
+                        }
+                    }
+                }
+            }
+
             ColumnLayout {
                 id: leftPane
                 Layout.fillHeight: true
@@ -77,13 +168,43 @@ ApplicationWindow {
                     placeholderText: "Search your passwords..."
 
                     style: TextFieldStyle {
-                        textColor: "white"
-                        placeholderTextColor: "#444"
+                        textColor: theme.strong
+                        placeholderTextColor: theme.placeholder
                         background: Rectangle {
                             radius: 5
-                            border.color: "#666"
+                            border.color: theme.control
                             border.width: 1
-                            color: "#333"
+                            color: theme.background
+                        }
+                    }
+                }
+
+                // Breadcrumbs for the folder shown, click one to go back up
+                Row {
+                    id: breadcrumbs
+                    Layout.fillWidth: true
+                    spacing: 4
+                    visible: passwords.folder !== ""
+
+                    Text {
+                        font.pixelSize: 14
+                        color: theme.dim
+                        text: "All"
+                        MouseArea {
+                            anchors.fill: parent
+                            onClicked: passwords.enter("")
+                        }
+                    }
+                    Repeater {
+                        model: passwords.folder === "" ? [] : passwords.folder.split("/")
+                        Text {
+                            font.pixelSize: 14
+                            color: index == passwords.folder.split("/").length - 1 ? theme.text : theme.dim
+                            text: "/ " + modelData
+                            MouseArea {
+                                anchors.fill: parent
+                                onClicked: passwords.enter(passwords.folder.split("/").slice(0, index + 1).join("/"))
+                            }
                         }
                     }
                 }
@@ -107,7 +228,7 @@ ApplicationWindow {
                         model: passwords.len
                         delegate: passwordEntry
                         highlight: Rectangle {
-                            color: "#444"
+                            color: theme.pane
                             radius: 3
                             anchors.left: parent ? parent.left : undefined
                             anchors.right: parent ? parent.right : undefined
@@ -125,7 +246,7 @@ ApplicationWindow {
                     z: -1
                     height: 14
                     font.pixelSize: 14
-                    color: "#aaa"
+                    color: theme.dim
                 }
             }
 
@@ -133,7 +254,7 @@ ApplicationWindow {
                 id: frame
                 width: 300;
                 Layout.fillHeight: true
-                color: "#444"
+                color: theme.pane
                 radius: 10
 
                 ColumnLayout {
@@ -169,6 +290,16 @@ ApplicationWindow {
                             anchors.topMargin: 24
                             fillMode: Image.PreserveAspectFit
                             source: "logo.svg"
+                            visible: !ui.decrypting
+                        }
+
+                        BusyIndicator {
+                            width: 48
+                            height: 48
+                            anchors.verticalCenter: parent.verticalCenter
+                            anchors.horizontalCenter: parent.horizontalCenter
+                            running: ui.decrypting
+                            visible: ui.decrypting
                         }
 
                         Image{
@@ -196,19 +327,22 @@ ApplicationWindow {
                             visible: true
                             contextType: "2d"
 
+                            property string ringColor: theme.progress
+
                             onCountdownChanged: progress.requestPaint()
+                            onRingColorChanged: progress.requestPaint()
                             onPaint: {
                                 var top = 3.0*(Math.PI/2.0)
                                 var cx = 50, cy = 50, r = 40, lw = 5
                                 var p = (countdown/15.0)
                                 context.reset()
                                 context.lineWidth = lw
-                                context.strokeStyle = cached?"#666":"#966"
+                                context.strokeStyle = cached ? theme.control : theme.warning
                                 context.arc(cx, cy, r, 0, 2.0*Math.PI , false)
                                 context.stroke()
 
                                 context.beginPath()
-                                context.strokeStyle = "#a6a"
+                                context.strokeStyle = ringColor
                                 context.lineWidth = lw
                                 context.arc(cx, cy, r, top, top-p*2.0*Math.PI, false)
                                 context.stroke()
@@ -220,14 +354,15 @@ ApplicationWindow {
                         anchors.horizontalCenter: parent.horizontalCenter
                         horizontalAlignment: Text.AlignHCenter
                         font.pixelSize: 18
+                        elide: Text.ElideMiddle
                         text: ui.password.name
-                        color: "#eee"
+                        color: theme.text
                     }
                     Rectangle {
                         id: rectangle1
                         height: 24
-                        color: "#555"
-                        border.color: "#444"
+                        color: theme.box
+                        border.color: theme.pane
                         border.width: 2
                         radius: 10
                         Layout.fillHeight: false
@@ -238,7 +373,7 @@ ApplicationWindow {
                         Text{
                             id: info
                             horizontalAlignment: Text.AlignHCenter
-                            color: "#aaa"
+                            color: theme.dim
                             padding: 5
                             font.pixelSize: 10
                             text: ui.password.info
@@ -246,6 +381,25 @@ ApplicationWindow {
                        }
                     }
 
+                    RoundButton {
+                        Layout.alignment: Qt.AlignHCenter
+                        label: "LOCK NOW"
+                        visible: ui.password.cached
+                        onClicked: ui.lock()
+                    }
+
+                    Text {
+                        id: recipients
+                        Layout.fillWidth: true
+                        Layout.leftMargin: 10
+                        Layout.rightMargin: 10
+                        color: theme.dim
+                        font.pixelSize: 10
+                        font.family: "Courier"
+                        elide: Text.ElideRight
+                        text: ui.password.recipients
+                    }
+
                     /*
                     RowLayout {
                         id: rowLayout1
@@ -274,61 +428,239 @@ ApplicationWindow {
                             transientScrollBars: true
                             scrollToClickedPosition : true
                         }
-                        TextEdit {
+                        ListView {
                             id: metadata
                             width: 270
-//                            anchors.fill: metadataContainer
-                            selectByMouse: true
-                            readOnly: true
-                            font.pixelSize: 12
-                            font.family: "Courier"
-                            color: "white"
-                            selectionColor: "#666"
-                            text: ui.password.metadata
-                            wrapMode: TextEdit.WrapAnywhere
+                            interactive: false
+                            height: contentHeight
+                            visible: ui.showMetadata
+                            model: revealed.len
+                            delegate: metadataField
                         }
                     }
+                    Text {
+                        Layout.fillWidth: true
+                        Layout.margins: 10
+                        visible: !ui.showMetadata
+                        horizontalAlignment: Text.AlignHCenter
+                        font.pixelSize: 12
+                        color: theme.dim
+                        text: ui.password.metadata
+                    }
                 }
             }
         }
 
 
-        Shortcut {
-            sequence:"Ctrl+K"
-            context: Qt.ApplicationShortcut
-            onActivated: hitList.decrementCurrentIndex()
-        }
+        // The shortcuts come from the key bindings, configured in Go
+        Instantiator {
+            model: shortcuts.len
+            delegate: Shortcut {
+                property var binding: shortcuts.get(index)
 
-        Shortcut {
-            sequence:"Up"
-            context: Qt.ApplicationShortcut
-            onActivated: hitList.decrementCurrentIndex()
+                sequence: binding.sequence
+                context: Qt.ApplicationShortcut
+                onActivated: rootWindow.runAction(binding.action)
+            }
         }
 
-        Shortcut {
-            sequence:"Ctrl+j"
-            onActivated: hitList.incrementCurrentIndex()
-        }
+        // Shows the problems found by the audit
+        Rectangle {
+            id: auditReport
+
+            anchors.fill: parent
+            radius: 10
+            color: theme.overlay
+            visible: ui.showAudit
+
+            MouseArea {
+                anchors.fill: parent
+            }
+
+            ColumnLayout {
+                anchors.fill: parent
+                anchors.margins: 20
+
+                Text {
+                    font.pixelSize: 18
+                    color: theme.text
+                    text: "Audit"
+                }
+
+                ScrollView {
+                    Layout.fillHeight: true
+                    Layout.fillWidth: true
+                    style: ScrollViewStyle{
+                        transientScrollBars: true
+                    }
+                    TextEdit {
+                        width: auditReport.width - 60
+                        readOnly: true
+                        selectByMouse: true
+                        font.pixelSize: 12
+                        font.family: "Courier"
+                        color: theme.strong
+                        selectionColor: theme.control
+                        wrapMode: TextEdit.Wrap
+                        text: ui.auditReport
+                    }
+                }
 
-        Shortcut {
-            sequence:"Down"
-            onActivated: hitList.incrementCurrentIndex()
+                RoundButton {
+                    Layout.alignment: Qt.AlignHCenter
+                    label: "CLOSE"
+                    onClicked: ui.closeAudit()
+                }
+            }
         }
 
-        Shortcut {
-            sequence:"Ctrl+r"
-            onActivated: ui.toggleShowMetadata()
+        // Lists the key bindings
+        Rectangle {
+            id: help
+
+            anchors.fill: parent
+            radius: 10
+            color: theme.overlay
+            visible: rootWindow.showHelp
+
+            MouseArea {
+                anchors.fill: parent
+                onClicked: rootWindow.showHelp = false
+            }
+
+            ColumnLayout {
+                anchors.fill: parent
+                anchors.margins: 20
+
+                Text {
+                    font.pixelSize: 18
+                    color: theme.text
+                    text: "Keys"
+                }
+
+                Text {
+                    Layout.fillHeight: true
+                    Layout.fillWidth: true
+                    font.pixelSize: 12
+                    font.family: "Courier"
+                    color: theme.strong
+                    text: shortcuts.help
+                }
+            }
         }
 
-        Shortcut {
-            sequence:"Ctrl+l"
-            onActivated: {searchInput.selectAll(); searchInput.focus=true}
+        // Asks for a passphrase when gpg-agent is set up to use loopback pinentry
+        Rectangle {
+            id: passphraseDialog
+
+            anchors.fill: parent
+            radius: 10
+            color: theme.overlay
+            visible: ui.askingPassphrase
+
+            onVisibleChanged: {
+                passphraseInput.text = ""
+                if (visible) {
+                    passphraseInput.forceActiveFocus()
+                } else {
+                    searchInput.forceActiveFocus()
+                }
+            }
+
+            MouseArea {
+                // Keep clicks from reaching the password list
+                anchors.fill: parent
+            }
+
+            ColumnLayout {
+                anchors.centerIn: parent
+                width: 400
+                spacing: 10
+
+                Text {
+                    Layout.fillWidth: true
+                    horizontalAlignment: Text.AlignHCenter
+                    font.pixelSize: 14
+                    color: theme.text
+                    wrapMode: Text.Wrap
+                    text: "Enter the passphrase for\n" + ui.passphraseHint
+                }
+
+                TextField {
+                    id: passphraseInput
+
+                    height: 42
+                    Layout.fillWidth: true
+                    font.pixelSize: 24
+                    echoMode: TextInput.Password
+
+                    onAccepted: {
+                        ui.submitPassphrase(text)
+                        text = ""
+                    }
+
+                    style: TextFieldStyle {
+                        textColor: theme.strong
+                        background: Rectangle {
+                            radius: 5
+                            border.color: theme.control
+                            border.width: 1
+                            color: theme.background
+                        }
+                    }
+                }
+
+                RoundButton {
+                    Layout.alignment: Qt.AlignHCenter
+                    label: "CANCEL"
+                    onClicked: ui.cancelPassphrase()
+                }
+            }
         }
 
-        Shortcut {
-            sequence: "Esc"
-            context: Qt.ApplicationShortcut
-            onActivated: ui.quit()
+        Component {
+            id: metadataField
+
+            Item {
+                // Fetched again whenever a field is revealed or masked
+                property var field: { revealed.revision; return revealed.get(index) }
+
+                width: 270
+                height: Math.max(key.height, value.height)
+
+                Text {
+                    id: key
+                    width: field.key ? 90 : 0
+                    font.pixelSize: 12
+                    font.family: "Courier"
+                    color: theme.dim
+                    elide: Text.ElideRight
+                    text: field.key
+                }
+                TextEdit {
+                    id: value
+                    anchors.left: key.right
+                    anchors.right: parent.right
+                    selectByMouse: !field.hidden
+                    readOnly: true
+                    font.pixelSize: 12
+                    font.family: "Courier"
+                    color: field.hidden ? theme.faint : theme.strong
+                    selectionColor: theme.control
+                    text: field.value
+                    wrapMode: TextEdit.WrapAnywhere
+                }
+                MouseArea {
+                    anchors.fill: key
+                    visible: field.secret
+                    onClicked: revealed.toggle(index)
+                }
+                MouseArea {
+                    anchors.fill: value
+                    visible: field.hidden
+                    onClicked: revealed.toggle(index)
+                }
+            }
         }
 
         Component {
@@ -337,17 +669,41 @@ ApplicationWindow {
             Text {
                 property var view: ListView.view
                 property int itemIndex: index
-
-                text: passwords.get(index).name;
+                property var entry: passwords.get(index)
+
+                width: view.width
+                leftPadding: entry.depth * 20
+                // The name is elided, and has the matches highlighted, already
+                text: entry.isFolder ? (entry.expanded ? "▾ " : "▸ ") + entry.display : (entry.favourite ? "★ " : "") + entry.display
+                textFormat: Text.StyledText
+                rightPadding: reason.width + 8
                 font.pixelSize: 18
-                color: ListView.isCurrentItem? "#dd00bb":"gray"
+                color: ListView.isCurrentItem ? theme.accent : entry.isFolder ? theme.folder : theme.entry
+
+                // Where the query matched
+                Text {
+                    id: reason
+                    anchors.right: parent.right
+                    anchors.verticalCenter: parent.verticalCenter
+                    font.pixelSize: 10
+                    color: theme.faint
+                    text: entry.reason
+                }
 
                 MouseArea{
                     anchors.fill: parent
-                    onClicked: view.currentIndex = itemIndex
+                    onClicked: {
+                        view.currentIndex = itemIndex
+                        if (entry.isFolder) {
+                            passwords.toggle(itemIndex)
+                        }
+                    }
                     onDoubleClicked: {
-                        clicked(passwordEntry)
-                        passwords.copyToClipboard(hitList.currentIndex)
+                        if (entry.isFolder) {
+                            passwords.enter(entry.path)
+                        } else {
+                            passwords.copyToClipboard(hitList.currentIndex)
+                        }
                     }
                 }
             }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeAssets(t *testing.T) {
+	custom := t.TempDir()
+	os.Mkdir(filepath.Join(custom, "icons"), 0700)
+	for name, data := range map[string]string{
+		"main.qml":        "custom main",
+		"icons/new.svg":   "<svg/>",
+		"RoundButton.qml": "custom button",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(custom, filepath.FromSlash(name)), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	merged, err := mergeAssets(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(merged)
+
+	builtin, err := embeddedAssets.ReadFile("assets/logo.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"main.qml":        "custom main",
+		"RoundButton.qml": "custom button",
+		"icons/new.svg":   "<svg/>",
+		"logo.svg":        string(builtin),
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(merged, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(data) != want {
+			t.Errorf("%s has %.20q, want %.20q", name, data, want)
+		}
+	}
+}
+
+func TestMergeAssetsMissingDir(t *testing.T) {
+	if merged, err := mergeAssets(filepath.Join(t.TempDir(), "missing")); err == nil {
+		os.RemoveAll(merged)
+		t.Error("merged a directory that doesn't exist")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/limetext/qml-go"
+)
+
+// maxPasswordAge is how long a password can go unchanged before the audit
+// flags it, set with GOPASS_AUDIT_MAX_AGE
+func maxPasswordAge() time.Duration {
+	age, err := time.ParseDuration(os.Getenv("GOPASS_AUDIT_MAX_AGE"))
+	if err != nil {
+		return 365 * 24 * time.Hour
+	}
+	return age
+}
+
+// AuditEntry is what the audit found out about one password
+type AuditEntry struct {
+	Name     string
+	Strength Strength
+	// Duplicates are the other passwords with the same secret
+	Duplicates []string
+	// Changed is when the password was last committed, zero if unknown
+	Changed time.Time
+	// Breached is set when the password is in the Have I Been Pwned list
+	Breached bool
+	Err      error
+}
+
+// Weak tells if the password is easy to guess
+func (e AuditEntry) Weak() bool {
+	return e.Err == nil && e.Strength.Score < 3
+}
+
+// Old tells if the password hasn't been changed in longer than maxAge
+func (e AuditEntry) Old(maxAge time.Duration) bool {
+	return !e.Changed.IsZero() && maxAge > 0 && time.Since(e.Changed) > maxAge
+}
+
+// AuditReport is the result of auditing all passwords
+type AuditReport struct {
+	Entries []AuditEntry
+	MaxAge  time.Duration
+	// CheckedBreaches is set when passwords were looked up in a
+	// Have I Been Pwned list, and BreachErr when that failed
+	CheckedBreaches bool
+	BreachErr       error
+}
+
+// Audit decrypts every password to check how strong it is, if it is
+// used more than once and how old it is. progress is called after each
+// password.
+func (ps *PasswordStore) Audit(progress func(done, total int)) AuditReport {
+	report := AuditReport{MaxAge: maxPasswordAge()}
+	passwords := ps.unique()
+
+	// Duplicates are found by comparing keyed hashes, so the secrets
+	// themselves don't need to stay in memory
+	salt := make([]byte, 32)
+	rand.Read(salt)
+	seen := make(map[string][]int)
+
+	var pwned *pwnedPasswords
+	if path := hibpPath(); path != "" {
+		pwned, report.BreachErr = openPwnedPasswords(path)
+		if pwned != nil {
+			defer pwned.Close()
+			report.CheckedBreaches = true
+		}
+	}
+
+	for i, pw := range passwords {
+		entry := AuditEntry{Name: pw.Name}
+		entry.Changed, _ = ps.lastChanged(pw)
+		secret, err := pw.Password()
+		if err != nil {
+			entry.Err = err
+		} else {
+			pass := bytes.TrimRight(secret.Bytes(), "\r\n")
+			entry.Strength = estimateStrength(pass)
+			mac := hmac.New(sha256.New, salt)
+			mac.Write(pass)
+			sum := string(mac.Sum(nil))
+			seen[sum] = append(seen[sum], i)
+			if pwned != nil && report.BreachErr == nil {
+				hash := sha1.Sum(pass)
+				var count int
+				count, report.BreachErr = pwned.Count(hash)
+				entry.Breached = count > 0
+				wipe(hash[:])
+			}
+			secret.Wipe()
+		}
+		report.Entries = append(report.Entries, entry)
+		if progress != nil {
+			progress(i+1, len(passwords))
+		}
+	}
+	wipe(salt)
+
+	for _, same := range seen {
+		if len(same) < 2 {
+			continue
+		}
+		for _, i := range same {
+			for _, j := range same {
+				if i != j {
+					report.Entries[i].Duplicates = append(report.Entries[i].Duplicates, report.Entries[j].Name)
+				}
+			}
+		}
+	}
+	return report
+}
+
+// unique lists the passwords sorted by name, each only once
+func (ps *PasswordStore) unique() []Password {
+	seen := make(map[string]bool)
+	var passwords []Password
+	for _, p := range ps.passwords {
+		if !seen[p.Path] {
+			seen[p.Path] = true
+			passwords = append(passwords, p)
+		}
+	}
+	sort.Slice(passwords, func(i, j int) bool { return passwords[i].Name < passwords[j].Name })
+	return passwords
+}
+
+// lastChanged asks git when the password was last committed
+func (ps *PasswordStore) lastChanged(pw Password) (time.Time, error) {
+	rel, err := filepath.Rel(ps.Prefix, pw.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	out, err := exec.Command("git", "-C", ps.Prefix, "log", "-1", "--format=%ct", "--", rel).Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	s := strings.TrimSpace(string(out))
+	if s == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// String lists the passwords with problems, and a summary
+func (r AuditReport) String() string {
+	var lines []string
+	var weak, reused, old, breached, failed int
+	for _, e := range r.Entries {
+		var problems []string
+		if e.Err != nil {
+			failed++
+			problems = append(problems, e.Err.Error())
+		}
+		if e.Weak() {
+			weak++
+			problems = append(problems, fmt.Sprintf("weak (score %d of 4)", e.Strength.Score))
+		}
+		if e.Breached {
+			breached++
+			problems = append(problems, "found in a breach")
+		}
+		if len(e.Duplicates) > 0 {
+			reused++
+			problems = append(problems, "same as "+strings.Join(e.Duplicates, ", "))
+		}
+		if e.Old(r.MaxAge) {
+			old++
+			problems = append(problems, fmt.Sprintf("unchanged since %s", e.Changed.Format("2006-01-02")))
+		}
+		if len(problems) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", e.Name, strings.Join(problems, "; ")))
+		}
+	}
+	summary := fmt.Sprintf("%d passwords: %d weak, %d reused, %d old, %d couldn't be decrypted",
+		len(r.Entries), weak, reused, old, failed)
+	if r.CheckedBreaches && r.BreachErr == nil {
+		summary += fmt.Sprintf(", %d found in breaches", breached)
+	}
+	lines = append(lines, summary)
+	if r.BreachErr != nil {
+		lines = append(lines, "Couldn't check for breaches: "+r.BreachErr.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func auditCommand(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	report := ps.Audit(func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\rAuditing %d/%d", done, total)
+	})
+	fmt.Fprintln(os.Stderr)
+	fmt.Println(report)
+	return nil
+}
+
+// Audit checks all passwords in the background and shows the report
+func (ui *UI) Audit() {
+	ui.idle.activity()
+	go func() {
+		report := ps.Audit(func(done, total int) {
+			ui.setStatus(fmt.Sprintf("Auditing %d/%d", done, total))
+		})
+		ui.AuditReport = report.String()
+		ui.ShowAudit = true
+		qml.Changed(ui, &ui.AuditReport)
+		qml.Changed(ui, &ui.ShowAudit)
+		ui.setStatus("Audit done")
+	}()
+}
+
+// CloseAudit hides the audit report
+func (ui *UI) CloseAudit() {
+	ui.ShowAudit = false
+	ui.AuditReport = ""
+	qml.Changed(ui, &ui.ShowAudit)
+	qml.Changed(ui, &ui.AuditReport)
+}
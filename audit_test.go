@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+	t.Setenv("GOPASS_HIBP", "")
+
+	store := &PasswordStore{Prefix: t.TempDir()}
+	for name, plaintext := range map[string]string{
+		"Bank":  "hunter2\naccount: 1234\n",
+		"Mail":  "hunter2\r\n",
+		"Git":   "X9#mQ2$vL7@pR4!z\n",
+		"Forum": "qwerty123\n",
+	} {
+		encrypted := encryptTest(t, c, plaintext, "alice@example.com")
+		if err := ioutil.WriteFile(filepath.Join(store.Prefix, name+".gpg"), encrypted, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file that can't be decrypted
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, "Broken.gpg"), []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store.indexAll()
+
+	var progress []int
+	report := store.Audit(func(done, total int) {
+		if total != 5 {
+			t.Errorf("progress out of %d, want 5", total)
+		}
+		progress = append(progress, done)
+	})
+	if !reflect.DeepEqual(progress, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("progress went %v", progress)
+	}
+	if report.CheckedBreaches {
+		t.Error("checked breaches without a list")
+	}
+
+	entries := make(map[string]AuditEntry)
+	for _, e := range report.Entries {
+		entries[e.Name] = e
+	}
+	for _, tc := range []struct {
+		name       string
+		weak       bool
+		duplicates []string
+	}{
+		// The line ending doesn't make a password different
+		{"Bank", true, []string{"Mail"}},
+		{"Mail", true, []string{"Bank"}},
+		{"Git", false, nil},
+		{"Forum", true, nil},
+	} {
+		e := entries[tc.name]
+		if e.Err != nil {
+			t.Errorf("%s: %v", tc.name, e.Err)
+		}
+		if e.Weak() != tc.weak {
+			t.Errorf("%s weak is %v, want %v", tc.name, e.Weak(), tc.weak)
+		}
+		if !reflect.DeepEqual(e.Duplicates, tc.duplicates) {
+			t.Errorf("%s reused in %v, want %v", tc.name, e.Duplicates, tc.duplicates)
+		}
+		// Not in git, so the age is unknown
+		if !e.Changed.IsZero() {
+			t.Errorf("%s changed at %v", tc.name, e.Changed)
+		}
+	}
+	if e := entries["Broken"]; e.Err == nil || e.Weak() {
+		t.Errorf("broken password audited as %+v", e)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+// D-Bus match rules for the signals sent when the screen saver starts
+var screenSaverMatches = []string{
+	"type='signal',interface='org.freedesktop.ScreenSaver',member='ActiveChanged'",
+	"type='signal',interface='org.gnome.ScreenSaver',member='ActiveChanged'",
+}
+
+// sessionLockMatch is the D-Bus match rule for logind locking the session
+// with the given object path. Other users' sessions being locked doesn't
+// concern us.
+func sessionLockMatch(path string) string {
+	return "type='signal',interface='org.freedesktop.login1.Session',member='Lock',path='" + path + "'"
+}
+
+// sessionPath asks logind for the object path of the session we're in
+func sessionPath() (string, error) {
+	args := []string{"--system", "--print-reply=literal", "--dest=org.freedesktop.login1",
+		"/org/freedesktop/login1"}
+	if id := os.Getenv("XDG_SESSION_ID"); id != "" {
+		args = append(args, "org.freedesktop.login1.Manager.GetSession", "string:"+id)
+	} else {
+		args = append(args, "org.freedesktop.login1.Manager.GetSessionByPID", fmt.Sprintf("uint32:%d", os.Getpid()))
+	}
+	out, err := exec.Command("dbus-send", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", errors.New("logind doesn't know our session")
+	}
+	return strings.Trim(fields[len(fields)-1], `"`), nil
+}
+
+// idleTimeout is how long the UI can go without being used before it locks,
+// set with GOPASS_IDLE_LOCK, 0 disables locking when idle
+func idleTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("GOPASS_IDLE_LOCK"))
+	if err != nil {
+		return 10 * time.Minute
+	}
+	return timeout
+}
+
+// idleLock locks the UI when it hasn't been used for a while
+type idleLock struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	timer   *time.Timer
+	locked  func()
+}
+
+func newIdleLock(timeout time.Duration, locked func()) *idleLock {
+	return &idleLock{timeout: timeout, locked: locked}
+}
+
+// activity restarts the idle timer
+func (l *idleLock) activity() {
+	if l == nil || l.timeout <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.timer == nil {
+		l.timer = time.AfterFunc(l.timeout, l.locked)
+		return
+	}
+	l.timer.Reset(l.timeout)
+}
+
+// watchScreenLock calls locked whenever the screen saver starts or the
+// session is locked, by following the D-Bus signals with dbus-monitor
+func watchScreenLock(locked func()) {
+	go monitorDBus("--session", screenSaverMatches, locked)
+	go func() {
+		path, err := sessionPath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Not watching for session lock:", err)
+			return
+		}
+		monitorDBus("--system", []string{sessionLockMatch(path)}, locked)
+	}()
+}
+
+func monitorDBus(bus string, matches []string, locked func()) {
+	cmd := exec.Command("dbus-monitor", append([]string{bus}, matches...)...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Not watching for screen lock:", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "Not watching for screen lock:", err)
+		return
+	}
+	parseMonitor(out, locked)
+	cmd.Wait()
+}
+
+// parseMonitor reads the output of dbus-monitor, calling locked for every
+// signal that means the screen was locked. Each message starts with an
+// unindented header line, followed by its indented arguments.
+func parseMonitor(r io.Reader, locked func()) {
+	var member string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && line[0] != ' ' {
+			// Only signals count, not method calls that happen to match
+			member = ""
+			if i := strings.Index(line, "member="); i >= 0 && strings.HasPrefix(line, "signal ") {
+				member = line[i+len("member="):]
+			}
+			if member == "Lock" {
+				locked()
+			}
+			continue
+		}
+		// ActiveChanged has the new state as its argument
+		if member == "ActiveChanged" && strings.TrimSpace(line) == "boolean true" {
+			locked()
+		}
+	}
+}
+
+// lock hides everything that was decrypted, clears the clipboard and
+// makes gpg-agent forget the passphrases of the keys used in the store.
+// Passphrases of other keys are left alone.
+func (ui *UI) lock(status string) {
+	if ui.countingDown {
+		ui.countdownDone <- true
+	}
+	decrypter.cancelAll()
+	ui.CancelPassphrase()
+	clipboard.WriteAll("")
+	ui.setCountdown(0)
+	ui.Clearmetadata()
+	if keyIDs := ps.KeyIDs(); len(keyIDs) > 0 {
+		if err := backend.Lock(keyIDs...); err != nil {
+			ui.setStatus(err.Error())
+			return
+		}
+	}
+	passwords.Update(status)
+}
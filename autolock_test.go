@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dbusMonitorOutput is what dbus-monitor prints when the screen saver
+// starts and stops, and when the session is locked
+const dbusMonitorOutput = `signal time=1697000000.000000 sender=org.freedesktop.DBus -> destination=:1.99 serial=2 path=/org/freedesktop/DBus; interface=org.freedesktop.DBus; member=NameAcquired
+   string ":1.99"
+signal time=1697000001.000000 sender=:1.23 -> destination=(null destination) serial=45 path=/org/freedesktop/ScreenSaver; interface=org.freedesktop.ScreenSaver; member=ActiveChanged
+   boolean true
+signal time=1697000002.000000 sender=:1.23 -> destination=(null destination) serial=46 path=/org/freedesktop/ScreenSaver; interface=org.freedesktop.ScreenSaver; member=ActiveChanged
+   boolean false
+method call time=1697000003.000000 sender=:1.50 -> destination=:1.23 serial=7 path=/org/freedesktop/ScreenSaver; interface=org.freedesktop.ScreenSaver; member=SetActive
+   boolean true
+signal time=1697000004.000000 sender=:1.2 -> destination=(null destination) serial=8 path=/org/freedesktop/login1/session/_32; interface=org.freedesktop.login1.Session; member=Lock
+`
+
+func TestParseMonitor(t *testing.T) {
+	locks := 0
+	parseMonitor(strings.NewReader(dbusMonitorOutput), func() { locks++ })
+	if locks != 2 {
+		t.Errorf("locked %d times, want 2", locks)
+	}
+}
+
+func TestIdleLock(t *testing.T) {
+	var locks int32
+	l := newIdleLock(50*time.Millisecond, func() { atomic.AddInt32(&locks, 1) })
+
+	// Nothing happens before the first activity
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&locks); n != 0 {
+		t.Fatalf("locked %d times without activity", n)
+	}
+
+	// Activity keeps it from locking
+	for i := 0; i < 5; i++ {
+		l.activity()
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&locks); n != 0 {
+		t.Fatalf("locked %d times while active", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&locks); n != 1 {
+		t.Errorf("locked %d times after being idle, want 1", n)
+	}
+}
+
+func TestIdleLockDisabled(t *testing.T) {
+	var locks int32
+	l := newIdleLock(0, func() { atomic.AddInt32(&locks, 1) })
+	l.activity()
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&locks); n != 0 {
+		t.Errorf("locked %d times with locking disabled", n)
+	}
+	// The UI may not have an idle lock at all
+	var none *idleLock
+	none.activity()
+}
+
+// TestMonitorDBus follows the signals on a private session bus, where the
+// screen saver and logind signals are sent by hand
+func TestMonitorDBus(t *testing.T) {
+	for _, tool := range []string{"dbus-daemon", "dbus-monitor", "dbus-send"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skip(tool, "not installed")
+		}
+	}
+	daemon := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	out, err := daemon.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer daemon.Wait()
+	defer daemon.Process.Kill()
+	address, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+
+	send := func(path, member string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("dbus-send", append([]string{"--session", "--type=signal", path, member}, args...)...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const ownSession = "/org/freedesktop/login1/session/_32"
+	var locks int32
+	matches := append([]string{sessionLockMatch(ownSession)}, screenSaverMatches...)
+	go monitorDBus("--session", matches, func() { atomic.AddInt32(&locks, 1) })
+
+	// dbus-monitor takes a moment to start listening, so the screen saver
+	// keeps starting until it's noticed
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&locks) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("screen saver starting never noticed")
+		}
+		send("/org/freedesktop/ScreenSaver", "org.freedesktop.ScreenSaver.ActiveChanged", "boolean:true")
+		time.Sleep(50 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	before := atomic.LoadInt32(&locks)
+
+	send("/org/freedesktop/ScreenSaver", "org.freedesktop.ScreenSaver.ActiveChanged", "boolean:false")
+	send("/org/freedesktop/login1/session/_33", "org.freedesktop.login1.Session.Lock")
+	send(ownSession, "org.freedesktop.login1.Session.Lock")
+	for atomic.LoadInt32(&locks) == before {
+		if time.Now().After(deadline) {
+			t.Fatal("session lock never noticed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&locks) - before; n != 1 {
+		t.Errorf("locked %d times, want 1 for our own session", n)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestName is the file in a backup listing the checksums of all other
+// files, in the format of sha256sum
+const manifestName = "MANIFEST.sha256"
+
+// backupFiles lists the encrypted passwords and .gpg-id files in the store,
+// relative to it
+func (ps *PasswordStore) backupFiles() ([]string, error) {
+	var files []string
+	err := filepath.Walk(ps.Prefix, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.Mode().IsRegular() && (strings.HasSuffix(path, ".gpg") || info.Name() == ".gpg-id") {
+			rel, err := filepath.Rel(ps.Prefix, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Backup writes a tar of the encrypted store to w, without decrypting
+// anything, with a manifest of checksums at the end
+func (ps *PasswordStore) Backup(w io.Writer) (int, error) {
+	files, err := ps.backupFiles()
+	if err != nil {
+		return 0, err
+	}
+	tw := tar.NewWriter(w)
+	var manifest bytes.Buffer
+	for _, name := range files {
+		data, err := ioutil.ReadFile(filepath.Join(ps.Prefix, filepath.FromSlash(name)))
+		if err != nil {
+			return 0, err
+		}
+		if err := writeTarFile(tw, name, data); err != nil {
+			return 0, err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	if err := writeTarFile(tw, manifestName, manifest.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(files), tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// BackupProblem is a file in a backup that is damaged or can't be decrypted
+type BackupProblem struct {
+	Name string
+	Err  error
+}
+
+// VerifyBackup checks the checksums in a backup, and that every password
+// in it can be decrypted with the keys we have
+func VerifyBackup(r io.Reader) (checked int, problems []BackupProblem, err error) {
+	sums := make(map[string]string)
+	var manifest []byte
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return checked, problems, err
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return checked, problems, err
+		}
+		if hdr.Name == manifestName {
+			manifest = data
+			continue
+		}
+		sum := sha256.Sum256(data)
+		sums[hdr.Name] = hex.EncodeToString(sum[:])
+		if strings.HasSuffix(hdr.Name, ".gpg") {
+			checked++
+			decrypted, err := backend.Decrypt(bytes.NewReader(data))
+			if err != nil {
+				problems = append(problems, BackupProblem{hdr.Name, err})
+				continue
+			}
+			decrypted.Wipe()
+		}
+	}
+	if manifest == nil {
+		return checked, problems, errors.New("No manifest in backup")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		want, name := parts[0], parts[1]
+		got, ok := sums[name]
+		delete(sums, name)
+		switch {
+		case !ok:
+			problems = append(problems, BackupProblem{name, errors.New("Missing from backup")})
+		case got != want:
+			problems = append(problems, BackupProblem{name, errors.New("Checksum doesn't match")})
+		}
+	}
+	for name := range sums {
+		problems = append(problems, BackupProblem{name, errors.New("Not in manifest")})
+	}
+	return checked, problems, scanner.Err()
+}
+
+func backupCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(args[0], ".gz") || strings.HasSuffix(args[0], ".tgz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	n, err := ps.Backup(w)
+	// The backup is only complete once everything is flushed to the file,
+	// and an incomplete one is removed
+	if gz != nil {
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(args[0])
+		return err
+	}
+	fmt.Printf("Backed up %d files\n", n)
+	return nil
+}
+
+func verifyBackupCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(args[0], ".gz") || strings.HasSuffix(args[0], ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		r = gz
+	}
+	checked, problems, err := VerifyBackup(r)
+	for _, p := range problems {
+		fmt.Printf("%s: %v\n", p.Name, p.Err)
+	}
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems in backup", len(problems))
+	}
+	fmt.Printf("All %d passwords can be decrypted\n", checked)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupCommand(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto, s *PasswordStore) { backend, ps = b, s }(backend, ps)
+	backend = c
+	ps = &PasswordStore{Prefix: t.TempDir()}
+	os.Mkdir(filepath.Join(ps.Prefix, "Work"), 0700)
+	for name, data := range map[string][]byte{
+		".gpg-id":      []byte("alice@example.com\n"),
+		"Mail.gpg":     encryptTest(t, c, "a", "alice@example.com"),
+		"Work/Git.gpg": encryptTest(t, c, "b", "alice@example.com"),
+	} {
+		if err := ioutil.WriteFile(filepath.Join(ps.Prefix, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"backup.tar", "backup.tgz"} {
+		path := filepath.Join(dir, name)
+		if err := backupCommand([]string{path}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := verifyBackupCommand([]string{path}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		// Existing files are never overwritten
+		if err := backupCommand([]string{path}); err == nil {
+			t.Errorf("%s was overwritten", name)
+		}
+	}
+}
+
+func TestBackupCommandFails(t *testing.T) {
+	defer func(s *PasswordStore) { ps = s }(ps)
+	ps = &PasswordStore{Prefix: filepath.Join(t.TempDir(), "missing")}
+	path := filepath.Join(t.TempDir(), "backup.tgz")
+	if err := backupCommand([]string{path}); err == nil {
+		t.Fatal("backed up a store that doesn't exist")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the incomplete backup was left behind")
+	}
+}
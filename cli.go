@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// A Command can be run from the command line instead of starting the UI
+type Command struct {
+	Usage string
+	Run   func(args []string) error
+}
+
+// errUsage is returned by commands when they are given the wrong arguments
+var errUsage = errors.New("Wrong arguments")
+
+var commands = map[string]Command{
+	"recipients":    {"recipients <name>", recipientsCommand},
+	"lock":          {"lock [name]", lockCommand},
+	"background":    {"background", backgroundCommand},
+	"audit":         {"audit", auditCommand},
+	"import":        {"import [-n] <keepass|bitwarden|1password|lastpass> <file>", importCommand},
+	"export":        {"export [--encrypt] <json|csv> [file]", exportCommand},
+	"backup":        {"backup <file.tar>", backupCommand},
+	"verify-backup": {"verify-backup <file.tar>", verifyBackupCommand},
+	"fav":           {"fav [name]", favCommand},
+	"unfav":         {"unfav <name>", unfavCommand},
+}
+
+// runCommand runs the command named by args[0]
+func runCommand(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("Unknown command %s\n%s", args[0], usage())
+	}
+	err := cmd.Run(args[1:])
+	if err == errUsage {
+		return fmt.Errorf("Usage: gopass %s", cmd.Usage)
+	}
+	return err
+}
+
+func usage() string {
+	var lines []string
+	for _, cmd := range commands {
+		lines = append(lines, "  gopass "+cmd.Usage)
+	}
+	sort.Strings(lines)
+	s := "Usage:\n  gopass"
+	for _, l := range lines {
+		s += "\n" + l
+	}
+	return s
+}
+
+// Lookup finds the password with the given name, as shown in the UI
+func (ps *PasswordStore) Lookup(name string) (Password, error) {
+	path := filepath.Join(ps.Prefix, name+".gpg")
+	if _, err := os.Stat(path); err != nil {
+		return Password{}, fmt.Errorf("No password named %s", name)
+	}
+	return Password{Name: name, Path: path}, nil
+}
+
+func recipientsCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	pw, err := ps.Lookup(args[0])
+	if err != nil {
+		return err
+	}
+	recipients, err := ps.Recipients(pw)
+	if err != nil {
+		return err
+	}
+	for _, r := range recipients {
+		fmt.Println(r)
+	}
+	return nil
+}
+
+func lockCommand(args []string) error {
+	switch len(args) {
+	case 0:
+		return backend.Lock()
+	case 1:
+		pw, err := ps.Lookup(args[0])
+		if err != nil {
+			return err
+		}
+		keyIDs, err := pw.KeyIDs()
+		if err != nil {
+			return err
+		}
+		return backend.Lock(keyIDs...)
+	}
+	return errUsage
+}
+
+// backgroundCommand starts the UI with the window hidden, and keeps it
+// running when the window is closed, so it shows up instantly next time
+func backgroundCommand(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	if conn, err := dialInstance(); err == nil {
+		conn.Close()
+		return errors.New("GoPass is already running")
+	}
+	ui.background = true
+	return runUI()
+}
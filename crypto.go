@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Crypto is a backend that knows how to decrypt and encrypt passwords
+type Crypto interface {
+	// Decrypt decrypts an encrypted password file
+	Decrypt(r io.Reader) (*Secret, error)
+	// Encrypt encrypts plaintext to the given recipients,
+	// as listed in a .gpg-id file
+	Encrypt(plaintext io.Reader, recipients []string) (io.Reader, error)
+	// RecipientsOf lists the IDs of the keys an encrypted file is encrypted to
+	RecipientsOf(r io.Reader) ([]uint64, error)
+	// KeyInfo looks up information about the key with the given ID
+	KeyInfo(keyID uint64) (KeyInfo, error)
+	// PublicKeys lists all keys in the keyring
+	PublicKeys() ([]*pubKey, error)
+	// KeysById finds the keys and subkeys with the given ID in the keyring
+	KeysById(id uint64) ([]*pubKey, error)
+	// Lock forgets the passphrases of the given keys,
+	// or of all keys when none are given
+	Lock(keyIDs ...uint64) error
+}
+
+// Errors returned when decrypting a password fails
+var (
+	errCancelled     = errors.New("Decryption cancelled")
+	errBadPassphrase = errors.New("Bad passphrase")
+	errNoSecretKey   = errors.New("No secret key available to decrypt this password")
+	errCorrupt       = errors.New("Password file is corrupt")
+)
+
+// PassphraseFunc asks the user for the passphrase of the secret key
+// described by hint. The caller wipes the passphrase after using it.
+type PassphraseFunc func(hint string) ([]byte, error)
+
+// passphraseAsker is implemented by backends that can ask for passphrases
+// themselves, instead of leaving it to gpg-agent's pinentry
+type passphraseAsker interface {
+	AskPassphrase(ask PassphraseFunc)
+}
+
+// pinentryLoopback tells if passphrases should be asked for in the gopass
+// window, which is turned on with GOPASS_PINENTRY=loopback
+func pinentryLoopback() bool {
+	return os.Getenv("GOPASS_PINENTRY") == "loopback"
+}
+
+// wipe overwrites a passphrase that is no longer needed
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// cryptoBackends holds a constructor for each crypto backend compiled in
+var cryptoBackends = map[string]func() (Crypto, error){}
+
+// backend is the Crypto used for all passwords
+var backend Crypto
+
+// newCrypto creates the crypto backend selected by GOPASS_CRYPTO,
+// preferring gpgme when nothing is selected
+func newCrypto() (Crypto, error) {
+	name := os.Getenv("GOPASS_CRYPTO")
+	if name == "" {
+		name = "gpgme"
+		if _, ok := cryptoBackends[name]; !ok {
+			name = "openpgp"
+		}
+	}
+	newBackend, ok := cryptoBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown crypto backend %q", name)
+	}
+	return newBackend()
+}
@@ -0,0 +1,324 @@
+//go:build !nogpgme
+// +build !nogpgme
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/proglottis/gpgme"
+)
+
+// GPGME gets very sad when run from lots of goroutines at the same time
+// this lock ensures that all operations are serialized.
+var gpgmeMutex sync.Mutex
+
+func init() {
+	cryptoBackends["gpgme"] = func() (Crypto, error) {
+		g := &gpgmeCrypto{
+			agent: newGPGAgent(agentSocket()),
+			cache: newAgentCache(),
+		}
+		g.keyring = newKeyringCache(exportKeys)
+		if err := g.keyring.watch(gnupgHome()); err != nil {
+			fmt.Fprintln(os.Stderr, "Not caching the keyring:", err)
+		}
+		return g, nil
+	}
+}
+
+// gpgmeCrypto uses gpgme and whatever gpg-agent is running
+type gpgmeCrypto struct {
+	keyring *keyringCache
+	agent   *gpgAgent
+	cache   *agentCache
+	// ask is set when passphrases are asked for with loopback pinentry
+	ask PassphraseFunc
+}
+
+// Error codes from libgpg-error, see gpg-error.h
+const (
+	gpgErrBadPassphrase gpgme.ErrorCode = 11
+	gpgErrInvPacket     gpgme.ErrorCode = 14
+	gpgErrNoSecretKey   gpgme.ErrorCode = 17
+	gpgErrNoData        gpgme.ErrorCode = 58
+	gpgErrBadData       gpgme.ErrorCode = 89
+	gpgErrCanceled      gpgme.ErrorCode = 99
+	gpgErrDecryptFailed gpgme.ErrorCode = 152
+	gpgErrFullyCanceled gpgme.ErrorCode = 198
+)
+
+// gpgmeError is an error with a libgpg-error code, like gpgme.Error
+type gpgmeError interface {
+	error
+	Code() gpgme.ErrorCode
+}
+
+// decryptError translates errors from gpgme into errors that make sense to the user
+func decryptError(err error) error {
+	e, ok := err.(gpgmeError)
+	if !ok {
+		return err
+	}
+	switch e.Code() {
+	case gpgErrCanceled, gpgErrFullyCanceled:
+		return errCancelled
+	case gpgErrBadPassphrase:
+		return errBadPassphrase
+	case gpgErrNoSecretKey:
+		return errNoSecretKey
+	case gpgErrInvPacket, gpgErrNoData, gpgErrBadData, gpgErrDecryptFailed:
+		return errCorrupt
+	}
+	return err
+}
+
+func (g *gpgmeCrypto) Decrypt(r io.Reader) (*Secret, error) {
+	ciphertext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out, err := g.decrypt(bytes.NewReader(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	// The agent has the passphrase cached for a while now
+	for _, k := range g.secretKeys(bytes.NewReader(ciphertext)) {
+		if keygrip, err := k.Keygrip(); err == nil {
+			g.cache.used(keygrip)
+		}
+	}
+	return out, nil
+}
+
+func (g *gpgmeCrypto) decrypt(r io.Reader) (*Secret, error) {
+	gpgmeMutex.Lock()
+	defer gpgmeMutex.Unlock()
+	if g.ask != nil {
+		return g.decryptLoopback(r)
+	}
+	out, err := gpgme.Decrypt(r)
+	if err != nil {
+		return nil, decryptError(err)
+	}
+	return readData(out)
+}
+
+// readData reads decrypted data into a Secret, overwriting what gpgme
+// still has of it
+func readData(d *gpgme.Data) (*Secret, error) {
+	defer d.Close()
+	s, err := readSecret(d)
+	if err != nil {
+		return nil, err
+	}
+	d.Seek(0, gpgme.SeekSet)
+	d.Write(make([]byte, s.Len()))
+	return s, nil
+}
+
+// decryptLoopback decrypts with gpg-agent asking us for the passphrase,
+// instead of starting its own pinentry
+func (g *gpgmeCrypto) decryptLoopback(r io.Reader) (*Secret, error) {
+	c, err := gpgme.New()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Release()
+	if err := c.SetPinEntryMode(gpgme.PinEntryLoopback); err != nil {
+		return nil, err
+	}
+	if err := c.SetCallback(g.passphrase); err != nil {
+		return nil, err
+	}
+	cipher, err := gpgme.NewDataReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cipher.Close()
+	plain, err := gpgme.NewData()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Decrypt(cipher, plain); err != nil {
+		plain.Close()
+		return nil, decryptError(err)
+	}
+	plain.Seek(0, gpgme.SeekSet)
+	return readData(plain)
+}
+
+// passphrase is the gpgme passphrase callback, which writes the
+// passphrase followed by a newline to f
+func (g *gpgmeCrypto) passphrase(uidHint string, prevWasBad bool, f *os.File) error {
+	hint := uidHint
+	if prevWasBad {
+		hint = "Bad passphrase, try again\n" + uidHint
+	}
+	pass, err := g.ask(hint)
+	if err != nil {
+		return err
+	}
+	defer wipe(pass)
+	if _, err := f.Write(pass); err != nil {
+		return err
+	}
+	_, err = f.Write([]byte("\n"))
+	return err
+}
+
+// AskPassphrase makes gpgme ask for passphrases with ask, but only when
+// loopback pinentry is configured, since gpg-agent's own pinentry is
+// usually the better choice
+func (g *gpgmeCrypto) AskPassphrase(ask PassphraseFunc) {
+	if pinentryLoopback() {
+		g.ask = ask
+	}
+}
+
+// secretKeys finds the keys in the keyring that an encrypted file is
+// encrypted to
+func (g *gpgmeCrypto) secretKeys(r io.Reader) []*pubKey {
+	keyIDs, _ := readRecipients(r)
+	var keys []*pubKey
+	for _, id := range keyIDs {
+		found, _ := g.KeysById(id)
+		keys = append(keys, found...)
+	}
+	return keys
+}
+
+func (g *gpgmeCrypto) Encrypt(plaintext io.Reader, recipients []string) (io.Reader, error) {
+	gpgmeMutex.Lock()
+	defer gpgmeMutex.Unlock()
+	var keys []*gpgme.Key
+	for _, r := range recipients {
+		found, err := gpgme.FindKeys(r, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("No public key for recipient %s", r)
+		}
+		keys = append(keys, found...)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("No recipients to encrypt to")
+	}
+
+	c, err := gpgme.New()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Release()
+	plain, err := gpgme.NewDataReader(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	defer plain.Close()
+	cipher, err := gpgme.NewData()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Encrypt(keys, gpgme.EncryptAlwaysTrust, plain, cipher); err != nil {
+		return nil, err
+	}
+	cipher.Seek(0, 0)
+	return cipher, nil
+}
+
+func (g *gpgmeCrypto) RecipientsOf(r io.Reader) ([]uint64, error) {
+	return readRecipients(r)
+}
+
+// exportKeys exports all public keys from gpg
+func exportKeys() ([]*pubKey, error) {
+	gpgmeMutex.Lock()
+	defer gpgmeMutex.Unlock()
+	c, err := gpgme.New()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Release()
+	allKeys, err := gpgme.NewData()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Export(0, allKeys); err != nil {
+		return nil, fmt.Errorf("error reading all keys: %s", err)
+	}
+	allKeys.Seek(0, 0)
+	keys, err := readPubKeys(allKeys)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open keyring: %s", err)
+	}
+	return keys, nil
+}
+
+func (g *gpgmeCrypto) PublicKeys() ([]*pubKey, error) {
+	return g.keyring.Keys()
+}
+
+func (g *gpgmeCrypto) KeysById(id uint64) ([]*pubKey, error) {
+	return g.keyring.KeysById(id)
+}
+
+func (g *gpgmeCrypto) KeyInfo(keyID uint64) (KeyInfo, error) {
+	keys, err := g.KeysById(keyID)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	// Get the keyInfo for the file
+	var ki KeyInfo
+	if len(keys) > 0 {
+		k := keys[0]
+		keygrip, err := k.Keygrip()
+		if err != nil {
+			return ki, err
+		}
+		ki.Fingerprint = k.KeyIdShortString()
+		ki.Algorithm = k.AlgorithmName()
+		ki.BitLength = k.BitLength()
+		if ki.GPGAgentKeyInfo, err = g.agent.KeyInfo(keygrip); err != nil {
+			return ki, err
+		}
+		ki.Secret = ki.KeyGrip != ""
+		if ki.Cached {
+			ki.CacheTTL = g.cache.remaining(keygrip)
+		} else {
+			g.cache.forget(keygrip)
+		}
+	}
+	return ki, nil
+}
+
+func (g *gpgmeCrypto) Lock(keyIDs ...uint64) error {
+	if len(keyIDs) == 0 {
+		g.cache.forgetAll()
+		return g.agent.ReloadAgent()
+	}
+	for _, id := range keyIDs {
+		keys, err := g.KeysById(id)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			keygrip, err := k.Keygrip()
+			if err != nil {
+				continue
+			}
+			if err := g.agent.ClearPassphrase(keygrip); err != nil {
+				return err
+			}
+			g.cache.forget(keygrip)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build !nogpgme
+// +build !nogpgme
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/proglottis/gpgme"
+)
+
+// fakeGPGError is an error from gpgme, which can't be made without cgo
+type fakeGPGError gpgme.ErrorCode
+
+func (e fakeGPGError) Error() string         { return fmt.Sprintf("gpg error %d", int(e)) }
+func (e fakeGPGError) Code() gpgme.ErrorCode { return gpgme.ErrorCode(e) }
+
+func TestDecryptError(t *testing.T) {
+	other := errors.New("something else")
+	unknown := fakeGPGError(1)
+	for _, tc := range []struct {
+		err, want error
+	}{
+		{fakeGPGError(gpgErrCanceled), errCancelled},
+		{fakeGPGError(gpgErrFullyCanceled), errCancelled},
+		{fakeGPGError(gpgErrBadPassphrase), errBadPassphrase},
+		{fakeGPGError(gpgErrNoSecretKey), errNoSecretKey},
+		{fakeGPGError(gpgErrInvPacket), errCorrupt},
+		{fakeGPGError(gpgErrNoData), errCorrupt},
+		{fakeGPGError(gpgErrBadData), errCorrupt},
+		{fakeGPGError(gpgErrDecryptFailed), errCorrupt},
+		{unknown, unknown},
+		{other, other},
+	} {
+		if got := decryptError(tc.err); got != tc.want {
+			t.Errorf("decryptError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
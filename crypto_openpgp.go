@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	pgperrors "golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+var errNoPassphrase = errors.New("No passphrase available for secret key")
+
+func init() {
+	cryptoBackends["openpgp"] = func() (Crypto, error) {
+		return newOpenPGPCrypto(secretKeyringPath())
+	}
+}
+
+// openpgpCrypto is a pure Go backend that reads keys from an exported
+// secret keyring, so it needs neither cgo nor a running gpg-agent
+type openpgpCrypto struct {
+	mu      sync.Mutex
+	keyring openpgp.EntityList
+	// supported is the part of the keyring openpgp can read,
+	// kept to read it again when locking
+	supported []byte
+	keys      *keyringCache
+	// Passphrase is asked for the passphrase of an encrypted secret key
+	Passphrase PassphraseFunc
+}
+
+// secretKeyringPath finds the keyring to use, as exported by
+// gpg --export-secret-keys
+func secretKeyringPath() string {
+	if p := os.Getenv("GOPASS_KEYRING"); p != "" {
+		return p
+	}
+	return filepath.Join(gnupgHome(), "secring.gpg")
+}
+
+// gnupgHome is the GnuPG home directory, as gpgconf knows it
+func gnupgHome() string {
+	out, err := exec.Command("gpgconf", "--list-dirs", "homedir").Output()
+	if err == nil && len(out) > 0 {
+		return strings.TrimSpace(string(out))
+	}
+	if p := os.Getenv("GNUPGHOME"); p != "" {
+		return p
+	}
+	return filepath.Join(homeDir(), ".gnupg")
+}
+
+// newOpenPGPCrypto creates an openpgpCrypto with the keys in the keyring
+// at path, which may be binary or ASCII armored
+func newOpenPGPCrypto(path string) (*openpgpCrypto, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		block, err := armor.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open keyring: %s", err)
+		}
+		if data, err = ioutil.ReadAll(block.Body); err != nil {
+			return nil, fmt.Errorf("Failed to open keyring: %s", err)
+		}
+	}
+	supported, err := filterKeyring(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open keyring: %s", err)
+	}
+	keyring, err := openpgp.ReadKeyRing(bytes.NewReader(supported))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open keyring: %s", err)
+	}
+	keys := newKeyringCache(func() ([]*pubKey, error) {
+		return readPubKeys(bytes.NewReader(data))
+	})
+	return &openpgpCrypto{keyring: keyring, supported: supported, keys: keys}, nil
+}
+
+// filterKeyring drops the keys golang.org/x/crypto/openpgp can't read from
+// a keyring, since it gives up on, or even panics on, the whole keyring otherwise
+func filterKeyring(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	var skipKey, skipSubkey bool
+	packets := packet.NewOpaqueReader(bytes.NewReader(data))
+	for {
+		p, err := packets.Next()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch p.Tag {
+		case tagPublicKey, tagSecretKey:
+			skipKey = !openpgpSupports(p)
+			skipSubkey = false
+		case tagPublicSubkey, tagSecretSubkey:
+			skipSubkey = !openpgpSupports(p)
+		}
+		if skipKey || skipSubkey {
+			continue
+		}
+		if err := p.Serialize(&out); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// openpgpSupports checks if golang.org/x/crypto/openpgp can read a key packet
+func openpgpSupports(p *packet.OpaquePacket) bool {
+	k, err := parsePubKey(p.Contents)
+	if err != nil {
+		return false
+	}
+	switch k.Algo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoRSASignOnly,
+		packet.PubKeyAlgoDSA, packet.PubKeyAlgoElGamal:
+		return true
+	case packet.PubKeyAlgoECDH, packet.PubKeyAlgoECDSA:
+		// Only public keys on the NIST curves
+		secret := p.Tag == tagSecretKey || p.Tag == tagSecretSubkey
+		return !secret && strings.HasPrefix(k.Curve.name, "NIST")
+	}
+	return false
+}
+
+// prompt asks for a passphrase to unlock one of the secret keys that can
+// decrypt a message
+func (c *openpgpCrypto) prompt(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+	if symmetric || c.Passphrase == nil {
+		return nil, errNoPassphrase
+	}
+	for _, k := range keys {
+		if k.PrivateKey == nil || !k.PrivateKey.Encrypted {
+			continue
+		}
+		hint := k.PublicKey.KeyIdString()
+		for name := range k.Entity.Identities {
+			hint += " " + name
+			break
+		}
+		pass, err := c.Passphrase(hint)
+		if err != nil {
+			return nil, err
+		}
+		err = k.PrivateKey.Decrypt(pass)
+		wipe(pass)
+		if err != nil {
+			return nil, errBadPassphrase
+		}
+		return nil, nil
+	}
+	return nil, errNoPassphrase
+}
+
+// openpgpError translates errors from openpgp into errors that make sense to the user
+func openpgpError(err error) error {
+	switch err.(type) {
+	case pgperrors.StructuralError, pgperrors.SignatureError:
+		return errCorrupt
+	}
+	if err == pgperrors.ErrKeyIncorrect {
+		return errNoSecretKey
+	}
+	return err
+}
+
+// entities returns the keyring, with whatever keys are unlocked
+func (c *openpgpCrypto) entities() openpgp.EntityList {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keyring
+}
+
+func (c *openpgpCrypto) Decrypt(r io.Reader) (*Secret, error) {
+	md, err := openpgp.ReadMessage(r, c.entities(), c.prompt, nil)
+	if err != nil {
+		return nil, openpgpError(err)
+	}
+	// Read everything, since the integrity check is only done at the end
+	out, err := readSecret(md.UnverifiedBody)
+	if err != nil {
+		return nil, openpgpError(err)
+	}
+	return out, nil
+}
+
+// findEntity finds the key for a recipient in a .gpg-id file, which is
+// either a key ID, a fingerprint or an email address
+func (c *openpgpCrypto) findEntity(recipient string) *openpgp.Entity {
+	id := strings.ToUpper(strings.TrimPrefix(recipient, "0x"))
+	for _, e := range c.entities() {
+		keys := []*openpgp.Subkey{{PublicKey: e.PrimaryKey}}
+		for i := range e.Subkeys {
+			keys = append(keys, &e.Subkeys[i])
+		}
+		for _, k := range keys {
+			if strings.HasSuffix(fmt.Sprintf("%X", k.PublicKey.Fingerprint), id) {
+				return e
+			}
+		}
+		for _, ident := range e.Identities {
+			if strings.EqualFold(ident.UserId.Email, strings.Trim(recipient, "<>")) {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (c *openpgpCrypto) Encrypt(plaintext io.Reader, recipients []string) (io.Reader, error) {
+	var to []*openpgp.Entity
+	for _, r := range recipients {
+		e := c.findEntity(r)
+		if e == nil {
+			return nil, fmt.Errorf("No public key for recipient %s", r)
+		}
+		to = append(to, e)
+	}
+	if len(to) == 0 {
+		return nil, errors.New("No recipients to encrypt to")
+	}
+	var out bytes.Buffer
+	w, err := openpgp.Encrypt(&out, to, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(w, plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *openpgpCrypto) RecipientsOf(r io.Reader) ([]uint64, error) {
+	return readRecipients(r)
+}
+
+func (c *openpgpCrypto) PublicKeys() ([]*pubKey, error) {
+	return c.keys.Keys()
+}
+
+func (c *openpgpCrypto) KeysById(id uint64) ([]*pubKey, error) {
+	return c.keys.KeysById(id)
+}
+
+func (c *openpgpCrypto) KeyInfo(keyID uint64) (KeyInfo, error) {
+	var ki KeyInfo
+	keys := c.entities().KeysById(keyID)
+	if len(keys) == 0 {
+		return ki, nil
+	}
+	theKey := keys[0].PublicKey
+	ki.Fingerprint = theKey.KeyIdShortString()
+	ki.Algorithm = algoString(theKey.PubKeyAlgo)
+	bl, _ := theKey.BitLength()
+	ki.BitLength = bl
+	// There is no agent, so a key is cached when its secret part is unlocked
+	ki.Secret = keys[0].PrivateKey != nil
+	ki.Cached = ki.Secret && !keys[0].PrivateKey.Encrypted
+	return ki, nil
+}
+
+// AskPassphrase sets how to ask for passphrases, since there is no other
+// way to unlock a key without gpg-agent
+func (c *openpgpCrypto) AskPassphrase(ask PassphraseFunc) {
+	c.Passphrase = ask
+}
+
+// Lock reads the keyring again, which locks all keys since openpgp
+// can't lock a single key once it is unlocked
+func (c *openpgpCrypto) Lock(keyIDs ...uint64) error {
+	keyring, err := openpgp.ReadKeyRing(bytes.NewReader(c.supported))
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyring = keyring
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	pgperrors "golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// testKeyConfig makes small keys, since they're only used in tests
+var testKeyConfig = &packet.Config{RSABits: 1024}
+
+// newTestKeyring generates a key, and writes it as an exported secret
+// keyring
+func newTestKeyring(t *testing.T, email string) (*openpgp.Entity, string) {
+	t.Helper()
+	e, err := openpgp.NewEntity("Test", "", email, testKeyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys made by gpg always prefer a hash, otherwise RIPEMD160 is used
+	for _, id := range e.Identities {
+		id.SelfSignature.PreferredHash = []uint8{8} // SHA256
+	}
+	var keyring bytes.Buffer
+	if err := e.SerializePrivate(&keyring, testKeyConfig); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "secring.gpg")
+	if err := ioutil.WriteFile(path, keyring.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return e, path
+}
+
+func newTestCrypto(t *testing.T, email string) (*openpgp.Entity, *openpgpCrypto) {
+	t.Helper()
+	e, path := newTestKeyring(t, email)
+	c, err := newOpenPGPCrypto(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, c
+}
+
+// newSharedTestCrypto is Alice's view of a store shared with Bob: she has
+// his public key, but only her own secret key
+func newSharedTestCrypto(t *testing.T) (alice, bob *openpgp.Entity, c *openpgpCrypto) {
+	t.Helper()
+	alice, path := newTestKeyring(t, "alice@example.com")
+	bob, _ = newTestKeyring(t, "bob@example.com")
+	var keyring bytes.Buffer
+	if err := bob.Serialize(&keyring); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring.Write(secret)
+	if err := ioutil.WriteFile(path, keyring.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err = newOpenPGPCrypto(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return alice, bob, c
+}
+
+func encryptTest(t *testing.T, c *openpgpCrypto, plaintext string, recipients ...string) []byte {
+	t.Helper()
+	r, err := c.Encrypt(bytes.NewReader([]byte(plaintext)), recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ciphertext
+}
+
+func TestOpenPGPRoundTrip(t *testing.T) {
+	e, c := newTestCrypto(t, "alice@example.com")
+	for _, recipient := range []string{
+		"alice@example.com",
+		"<alice@example.com>",
+		fmt.Sprintf("%X", e.PrimaryKey.Fingerprint),
+		fmt.Sprintf("0x%016X", e.PrimaryKey.KeyId),
+	} {
+		ciphertext := encryptTest(t, c, "hunter2\nuser: alice\n", recipient)
+		plaintext, err := c.Decrypt(bytes.NewReader(ciphertext))
+		if err != nil {
+			t.Fatalf("%s: %v", recipient, err)
+		}
+		if got := plaintext.String(); got != "hunter2\nuser: alice\n" {
+			t.Errorf("%s: decrypted %q", recipient, got)
+		}
+		plaintext.Wipe()
+	}
+}
+
+func TestOpenPGPEncryptUnknownRecipient(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	if _, err := c.Encrypt(bytes.NewReader([]byte("x")), []string{"bob@example.com"}); err == nil {
+		t.Error("encrypted to a recipient without a key")
+	}
+	if _, err := c.Encrypt(bytes.NewReader([]byte("x")), nil); err == nil {
+		t.Error("encrypted to nobody")
+	}
+}
+
+func TestOpenPGPRecipientsOf(t *testing.T) {
+	e, c := newTestCrypto(t, "alice@example.com")
+	ciphertext := encryptTest(t, c, "x", "alice@example.com")
+	keyIDs, err := c.RecipientsOf(bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Messages are encrypted to the encryption subkey
+	want := e.Subkeys[0].PublicKey.KeyId
+	if len(keyIDs) != 1 || keyIDs[0] != want {
+		t.Errorf("got recipients %X, want %X", keyIDs, want)
+	}
+}
+
+func TestOpenPGPKeyInfo(t *testing.T) {
+	e, c := newTestCrypto(t, "alice@example.com")
+	ki, err := c.KeyInfo(e.Subkeys[0].PublicKey.KeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ki.Algorithm != "RSA" || ki.BitLength != 1024 {
+		t.Errorf("got %s %d, want RSA 1024", ki.Algorithm, ki.BitLength)
+	}
+	if ki.Fingerprint != e.Subkeys[0].PublicKey.KeyIdShortString() {
+		t.Errorf("got fingerprint %s", ki.Fingerprint)
+	}
+	// The generated key has no passphrase, so it's always unlocked
+	if !ki.Secret || !ki.Cached {
+		t.Error("unprotected secret key isn't cached")
+	}
+
+	ki, err = c.KeyInfo(0x1234)
+	if err != nil || ki.Algorithm != "" {
+		t.Errorf("unknown key gave %+v, %v", ki, err)
+	}
+}
+
+func TestOpenPGPDecryptErrors(t *testing.T) {
+	_, alice := newTestCrypto(t, "alice@example.com")
+	_, bob := newTestCrypto(t, "bob@example.com")
+	ciphertext := encryptTest(t, alice, "hunter2", "alice@example.com")
+
+	if _, err := bob.Decrypt(bytes.NewReader(ciphertext)); err != errNoSecretKey {
+		t.Errorf("decrypting with the wrong key gave %v, want %v", err, errNoSecretKey)
+	}
+	if _, err := alice.Decrypt(bytes.NewReader([]byte("not a message"))); err != errCorrupt {
+		t.Errorf("decrypting garbage gave %v, want %v", err, errCorrupt)
+	}
+}
+
+func TestOpenPGPError(t *testing.T) {
+	other := fmt.Errorf("something else")
+	for _, tc := range []struct {
+		err, want error
+	}{
+		{pgperrors.StructuralError("bad packet"), errCorrupt},
+		{pgperrors.SignatureError("bad MDC"), errCorrupt},
+		{pgperrors.ErrKeyIncorrect, errNoSecretKey},
+		{other, other},
+	} {
+		if got := openpgpError(tc.err); got != tc.want {
+			t.Errorf("openpgpError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
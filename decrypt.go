@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/limetext/qml-go"
+)
+
+// decryptJob is a request to decrypt something from a password file
+type decryptJob struct {
+	id   uint64
+	name string
+	ctx  context.Context
+	run  func(ctx context.Context) (*Secret, error)
+	done func(plaintext *Secret, err error)
+}
+
+// decryptWorker decrypts one password at a time in the background, so the
+// UI keeps running while pinentry asks for a passphrase. Requests that are
+// cancelled before they're started are dropped, and results of requests
+// that were cancelled while running are thrown away.
+//
+// A running request can't always be stopped: gpgme has no way to abort a
+// decryption that's waiting for gpg-agent's own pinentry, and holds
+// gpgmeMutex until the user answers it. The worker stays busy until then,
+// and later requests wait for it.
+type decryptWorker struct {
+	mu sync.Mutex
+	// queue has the requests that haven't been started yet, in order
+	queue   []decryptJob
+	wake    chan bool
+	lastID  uint64
+	pending map[uint64]decryptJob
+	cancels map[uint64]context.CancelFunc
+	// running is set while a request is being worked on, even when it
+	// has been cancelled, and current is that request's context
+	running bool
+	current context.Context
+	// busy is called whenever the worker starts or stops having
+	// requests to work on
+	busy func(bool)
+	// runMain runs a function on the main thread
+	runMain func(func())
+}
+
+func newDecryptWorker(busy func(bool)) *decryptWorker {
+	w := &decryptWorker{
+		wake:    make(chan bool, 1),
+		pending: make(map[uint64]decryptJob),
+		cancels: make(map[uint64]context.CancelFunc),
+		busy:    busy,
+		runMain: qml.RunMain,
+	}
+	go w.loop()
+	return w
+}
+
+func (w *decryptWorker) loop() {
+	for range w.wake {
+		for {
+			job, ok := w.next()
+			if !ok {
+				break
+			}
+			plaintext, err := job.run(job.ctx)
+			w.runMain(func() {
+				if w.finish(job.id) {
+					job.done(plaintext, err)
+				} else {
+					plaintext.Wipe()
+				}
+			})
+			w.mu.Lock()
+			w.running = false
+			w.current = nil
+			idle := len(w.pending) == 0
+			w.mu.Unlock()
+			if idle {
+				w.busy(false)
+			}
+		}
+	}
+}
+
+// next takes the first request from the queue
+func (w *decryptWorker) next() (decryptJob, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) == 0 {
+		return decryptJob{}, false
+	}
+	job := w.queue[0]
+	w.queue = w.queue[1:]
+	w.running = true
+	w.current = job.ctx
+	return job, true
+}
+
+// currentContext is the context of the running request, for things like
+// the passphrase dialog that are called from inside the crypto backend
+func (w *decryptWorker) currentContext() context.Context {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.current == nil {
+		return context.Background()
+	}
+	return w.current
+}
+
+// request queues run to be done in the background for the password with
+// the given name, and done to be called with the result on the main thread.
+// run is given a context that is cancelled when the request is. It never
+// blocks, so it can be called from the main thread.
+func (w *decryptWorker) request(name string, run func(context.Context) (*Secret, error), done func(*Secret, error)) uint64 {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.mu.Lock()
+	first := len(w.pending) == 0 && !w.running
+	w.lastID++
+	job := decryptJob{id: w.lastID, name: name, ctx: ctx, run: run, done: done}
+	w.pending[job.id] = job
+	w.cancels[job.id] = cancel
+	w.queue = append(w.queue, job)
+	w.mu.Unlock()
+	if first {
+		w.busy(true)
+	}
+	select {
+	case w.wake <- true:
+	default:
+		// The worker is already awake, and will find the request
+	}
+	return job.id
+}
+
+// finish removes a request, returning false if it was cancelled
+func (w *decryptWorker) finish(id uint64) bool {
+	w.mu.Lock()
+	_, ok := w.pending[id]
+	if ok {
+		w.cancels[id]()
+		delete(w.pending, id)
+		delete(w.cancels, id)
+		for i, job := range w.queue {
+			if job.id == id {
+				w.queue = append(w.queue[:i], w.queue[i+1:]...)
+				break
+			}
+		}
+	}
+	idle := len(w.pending) == 0 && !w.running
+	w.mu.Unlock()
+	if ok && idle {
+		w.busy(false)
+	}
+	return ok
+}
+
+// cancelOthers cancels all requests that aren't for the password with
+// the given name
+func (w *decryptWorker) cancelOthers(name string) {
+	w.cancelWhere(func(job decryptJob) bool { return job.name != name })
+}
+
+// cancelAll cancels all requests
+func (w *decryptWorker) cancelAll() {
+	w.cancelWhere(func(decryptJob) bool { return true })
+}
+
+func (w *decryptWorker) cancelWhere(match func(decryptJob) bool) {
+	w.mu.Lock()
+	var ids []uint64
+	for id, job := range w.pending {
+		if match(job) {
+			ids = append(ids, id)
+		}
+	}
+	w.mu.Unlock()
+	for _, id := range ids {
+		w.finish(id)
+	}
+}
+
+// decrypt runs part of decrypting pw in the background, telling the user
+// when it's waiting for their passphrase, and calls done with the result
+// unless the request is cancelled first. done is responsible for wiping
+// the result.
+func (ui *UI) decrypt(pw Password, run func() (*Secret, error), done func(*Secret)) {
+	ui.setStatus("Decrypting…")
+	decrypter.request(pw.Name, func(ctx context.Context) (*Secret, error) {
+		// Asking gpg-agent if the key is unlocked can take a while, so
+		// it's not done on the main thread
+		if !pw.isCached() {
+			decrypter.runMain(func() {
+				if ctx.Err() == nil {
+					ui.setStatus("Waiting for passphrase…")
+				}
+			})
+		}
+		return run()
+	}, func(plaintext *Secret, err error) {
+		if err != nil {
+			ui.setStatus(err.Error())
+			return
+		}
+		done(plaintext)
+	})
+}
+
+func (ui *UI) setDecrypting(d bool) {
+	ui.Decrypting = d
+	qml.Changed(ui, &ui.Decrypting)
+}
+
+var decrypter *decryptWorker
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestWorker makes a decryptWorker that delivers results right away,
+// instead of on the QML main thread
+func newTestWorker() *decryptWorker {
+	w := newDecryptWorker(func(bool) {})
+	w.runMain = func(f func()) { f() }
+	return w
+}
+
+func TestDecryptWorkerDoesNotBlock(t *testing.T) {
+	w := newTestWorker()
+	started, release := make(chan bool), make(chan bool)
+	w.request("first", func(ctx context.Context) (*Secret, error) {
+		started <- true
+		<-release
+		return nil, nil
+	}, func(*Secret, error) {})
+	<-started
+
+	// Lots of requests while the worker is busy, as when scrolling
+	// through passwords while pinentry is open
+	requested := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			w.request("other", func(context.Context) (*Secret, error) {
+				t.Error("ran a cancelled request")
+				return nil, nil
+			}, func(*Secret, error) {})
+		}
+		requested <- true
+	}()
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request blocked while the worker was busy")
+	}
+	w.cancelOthers("first")
+	w.mu.Lock()
+	if len(w.queue) != 0 {
+		t.Errorf("%d cancelled requests are still queued", len(w.queue))
+	}
+	w.mu.Unlock()
+	close(release)
+}
+
+func TestDecryptWorkerCancelRunning(t *testing.T) {
+	w := newTestWorker()
+	started := make(chan bool)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var got error
+	w.request("pw", func(ctx context.Context) (*Secret, error) {
+		defer wg.Done()
+		started <- true
+		// The running request sees when it's cancelled
+		select {
+		case <-ctx.Done():
+			got = ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+		return nil, got
+	}, func(*Secret, error) {
+		t.Error("delivered the result of a cancelled request")
+	})
+	<-started
+	w.cancelAll()
+	wg.Wait()
+	if got != context.Canceled {
+		t.Errorf("running request got %v, want it cancelled", got)
+	}
+}
+
+func TestDecryptWorkerDone(t *testing.T) {
+	w := newTestWorker()
+	var busy []bool
+	var mu sync.Mutex
+	w.busy = func(b bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		busy = append(busy, b)
+	}
+	done := make(chan string)
+	w.request("pw", func(context.Context) (*Secret, error) {
+		return secretFrom([]byte("hunter2")), nil
+	}, func(s *Secret, err error) {
+		done <- s.String()
+		s.Wipe()
+	})
+	select {
+	case got := <-done:
+		if got != "hunter2" {
+			t.Errorf("got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request wasn't done")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(busy) != 2 || !busy[0] || busy[1] {
+		t.Errorf("busy went %v, want [true false]", busy)
+	}
+}
+
+func TestDecryptWorkerBusyUntilRunReturns(t *testing.T) {
+	w := newTestWorker()
+	busy := make(chan bool, 10)
+	w.busy = func(b bool) { busy <- b }
+	started, release := make(chan bool), make(chan bool)
+	w.request("pw", func(context.Context) (*Secret, error) {
+		// Like gpgme waiting for gpg-agent's pinentry, which doesn't
+		// notice the request being cancelled
+		started <- true
+		<-release
+		return nil, nil
+	}, func(*Secret, error) {})
+	if b := <-busy; !b {
+		t.Fatal("not busy after a request")
+	}
+	<-started
+
+	w.cancelAll()
+	w.request("other", func(context.Context) (*Secret, error) {
+		return nil, nil
+	}, func(*Secret, error) {})
+	w.cancelAll()
+	select {
+	case b := <-busy:
+		t.Fatalf("busy went %v while a request was still running", b)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case b := <-busy:
+		if b {
+			t.Error("busy again after the running request returned")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("still busy after the running request returned")
+	}
+}
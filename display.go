@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDisplayLen is about how many characters of a name fit in the list
+const maxDisplayLen = 48
+
+// elide picks the parts of a name to show when it's longer than max
+// characters. The first folder and the password itself are kept as long as
+// possible, leaving out folders in the middle. When the first folder
+// doesn't fit, as many of the folders before the password as fit are
+// kept, and only then the middle of the password name is left out. The
+// parts are returned as byte ranges.
+func elide(name string, max int) [][2]int {
+	if utf8.RuneCountInString(name) <= max {
+		return [][2]int{{0, len(name)}}
+	}
+	last := strings.LastIndex(name, "/") + 1
+	first := strings.Index(name, "/") + 1
+	if first > 0 && first < last {
+		// Keep the first folder, "…", and as many of the folders before
+		// the password as fit
+		budget := max - utf8.RuneCountInString(name[:first]) - 1
+		if tail := trailingFolders(name, last, budget); utf8.RuneCountInString(name[tail:]) <= budget {
+			return [][2]int{{0, first}, {tail, len(name)}}
+		}
+	}
+	if last > 0 {
+		// "…" and the folders before the password, so passwords with the
+		// same name in different folders still look different
+		if tail := trailingFolders(name, last, max-1); tail < last-1 {
+			return [][2]int{{tail, len(name)}}
+		}
+	}
+	// Only the password name, with its middle left out if needed
+	base := name[last:]
+	if utf8.RuneCountInString(base) <= max-1 {
+		return [][2]int{{last, len(name)}}
+	}
+	runes := []rune(base)
+	head := len(string(runes[:(max-1)/2]))
+	tail := len(string(runes[len(runes)-(max-1)/2:]))
+	return [][2]int{{last, last + head}, {len(name) - tail, len(name)}}
+}
+
+// trailingFolders finds where to start showing name so it ends with as
+// many whole folders before the password as fit in budget characters. The
+// result is the index of a "/", last-1 when not even one folder fits.
+func trailingFolders(name string, last, budget int) int {
+	tail := last - 1
+	for {
+		prev := strings.LastIndex(name[:tail], "/")
+		if prev < 0 || utf8.RuneCountInString(name[prev:]) > budget {
+			return tail
+		}
+		tail = prev
+	}
+}
+
+// spanColor is the color matches are highlighted with, by where they were
+// found, "" when they aren't highlighted
+func spanColor(source MatchSource) string {
+	switch source {
+	case MatchName:
+		return theme.Match
+	case MatchFolder:
+		return theme.MatchFolder
+	}
+	return ""
+}
+
+// displayName shows a name elided to fit, with the spans that matched a
+// query highlighted, as styled text for QML. The spans start at offset in
+// the name they were found in, which can be longer than the name shown.
+func displayName(name string, spans []Span, offset, max int) string {
+	matched := make([]string, len(name))
+	for _, s := range spans {
+		color := spanColor(s.Source)
+		if color == "" {
+			continue
+		}
+		for i := s.Start - offset; i < s.End-offset; i++ {
+			if i >= 0 && i < len(name) {
+				matched[i] = color
+			}
+		}
+	}
+	var b bytes.Buffer
+	for i, part := range elide(name, max) {
+		if i > 0 || part[0] > 0 {
+			b.WriteString("…")
+		}
+		for start := part[0]; start < part[1]; {
+			end := start
+			for end < part[1] && matched[end] == matched[start] {
+				end++
+			}
+			text := html.EscapeString(name[start:end])
+			if color := matched[start]; color != "" {
+				b.WriteString(`<b><font color="` + color + `">` + text + "</font></b>")
+			} else {
+				b.WriteString(text)
+			}
+			start = end
+		}
+	}
+	return b.String()
+}
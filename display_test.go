@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+// elided joins the parts of name elide picked, the way displayName does
+func elided(name string, max int) string {
+	var s string
+	for i, part := range elide(name, max) {
+		if i > 0 || part[0] > 0 {
+			s += "…"
+		}
+		s += name[part[0]:part[1]]
+	}
+	return s
+}
+
+func TestElide(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		max  int
+		want string
+	}{
+		{"Work/Mail", 20, "Work/Mail"},
+		{"Work/Mail/Google/alice", 22, "Work/Mail/Google/alice"},
+		// Folders in the middle go first
+		{"Work/a/b/c/Mail", 12, "Work/…/Mail"},
+		{"Work/a/b/c/Mail", 14, "Work/…/c/Mail"},
+		// When the first folder doesn't fit, the folders closest to the
+		// password are kept, so these still look different
+		{"averyveryverylongfolder/prod/db", 20, "…/prod/db"},
+		{"averyveryverylongfolder/staging/db", 20, "…/staging/db"},
+		{"averyveryverylongfolder/eu/prod/db", 15, "…/eu/prod/db"},
+		{"averyveryverylongfolder/eu/prod/db", 10, "…/prod/db"},
+		// Then the middle of the password name
+		{"Work/Mail/Google/alice@gmail.com", 20, "…alice@gmail.com"},
+		{"a/b/c/d/e/f/longpassword", 12, "…longp…sword"},
+		{"averyveryverylongpasswordname", 11, "avery…dname"},
+		// Lengths are in characters, not bytes
+		{"ÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄ/ö/ü", 10, "…/ö/ü"},
+		{"Übersicht/Schlüssel", 19, "Übersicht/Schlüssel"},
+		{"日本語日本語日本語日本語日本語", 9, "日本語日…語日本語"},
+	} {
+		if got := elided(tc.name, tc.max); got != tc.want {
+			t.Errorf("elide(%q, %d) shows %q, want %q", tc.name, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	defer func(th Theme) { theme = th }(theme)
+	theme.Match = "red"
+	theme.MatchFolder = "blue"
+
+	for _, tc := range []struct {
+		name   string
+		spans  []Span
+		offset int
+		max    int
+		want   string
+	}{
+		{"Mail", nil, 0, 20, "Mail"},
+		{"<b>&", nil, 0, 20, "&lt;b&gt;&amp;"},
+		{"Gmail", []Span{{Start: 1, End: 4, Source: MatchName}}, 0, 20,
+			`G<b><font color="red">mai</font></b>l`},
+		// Metadata matches aren't highlighted in the name
+		{"Gmail", []Span{{Start: 1, End: 4, Source: MatchMetadata}}, 0, 20, "Gmail"},
+		// Spans are in the full name "Work/Mail/Gmail", but only "Gmail"
+		// is shown
+		{"Gmail", []Span{{Start: 0, End: 4, Source: MatchFolder}, {Start: 10, End: 12, Source: MatchName}}, 10, 20,
+			`<b><font color="red">Gm</font></b>ail`},
+		{"Gmail", []Span{{Start: 8, End: 12, Source: MatchName}}, 10, 20,
+			`<b><font color="red">Gm</font></b>ail`},
+		// Highlights in the part that's left out disappear, the rest stay
+		{"averyveryverylongfolder/prod/db", []Span{{Start: 1, End: 5, Source: MatchFolder}, {Start: 24, End: 28, Source: MatchFolder}}, 0, 20,
+			`…/<b><font color="blue">prod</font></b>/db`},
+		// Spans are byte offsets, so they work with multibyte names
+		{"Übersicht/Schlüssel", []Span{{Start: 15, End: 21, Source: MatchName}}, 0, 20,
+			`Übersicht/Schl<b><font color="red">üssel</font></b>`},
+		{"ÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄ/ö/ü", []Span{{Start: 44, End: 46, Source: MatchName}}, 0, 10,
+			`…/ö/<b><font color="red">ü</font></b>`},
+	} {
+		if got := displayName(tc.name, tc.spans, tc.offset, tc.max); got != tc.want {
+			t.Errorf("displayName(%q, %v, %d, %d) = %q, want %q", tc.name, tc.spans, tc.offset, tc.max, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// editorCommand is the command passwords are edited with, from
+// GOPASS_EDITOR or VISUAL. It has to open a window of its own, and not
+// return before the file is closed.
+func editorCommand() ([]string, error) {
+	for _, env := range []string{"GOPASS_EDITOR", "VISUAL"} {
+		if cmd := strings.Fields(os.Getenv(env)); len(cmd) > 0 {
+			return cmd, nil
+		}
+	}
+	return nil, errors.New("Set GOPASS_EDITOR to edit passwords")
+}
+
+// editDir is where decrypted passwords are kept while they're edited,
+// preferably somewhere that is never written to disk
+func editDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// Edit lets the user change a decrypted password in an editor, and
+// encrypts it again if it changed. It tells if it was changed.
+func (ps *PasswordStore) Edit(pw Password, plaintext *Secret, editor []string) (bool, error) {
+	dir, err := ioutil.TempDir(editDir(), "gopass-edit")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, path.Base(pw.Name)+".txt")
+	if err := ioutil.WriteFile(file, plaintext.Bytes(), 0600); err != nil {
+		return false, err
+	}
+	defer overwrite(file)
+
+	cmd := exec.Command(editor[0], append(editor[1:], file)...)
+	if err := cmd.Run(); err != nil {
+		return false, fmt.Errorf("Editor failed: %v", err)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	edited, err := readSecret(f)
+	f.Close()
+	if err != nil {
+		return false, err
+	}
+	defer edited.Wipe()
+	if bytes.Equal(edited.Bytes(), plaintext.Bytes()) {
+		return false, nil
+	}
+	if err := ps.encryptNew(pw, edited.Bytes()); err != nil {
+		return true, err
+	}
+	return true, ps.gitCommit("Edit password for "+pw.Name+" using gopass.", pw.Path)
+}
+
+// overwrite fills a file with zeros, so the decrypted password isn't left
+// behind when it's removed
+func overwrite(file string) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(file, make([]byte, info.Size()), 0600)
+}
+
+// Edit opens the selected password in an editor
+func (p *Passwords) Edit(selected int) {
+	ui.idle.activity()
+	editor, err := editorCommand()
+	if err != nil {
+		ui.setStatus(err.Error())
+		return
+	}
+	if selected < 0 || selected >= len(p.rows) || p.rows[selected].IsFolder {
+		ui.setStatus("No password selected")
+		return
+	}
+	pw := p.rows[selected].pw
+	ui.decrypt(pw, pw.decrypt, func(plaintext *Secret) {
+		ui.setStatus("Editing " + pw.Name)
+		go func() {
+			defer plaintext.Wipe()
+			changed, err := p.store.Edit(pw, plaintext, editor)
+			switch {
+			case err != nil:
+				p.Update(fmt.Sprintf("Couldn't edit %s: %v", pw.Name, err))
+			case changed:
+				p.Update("Saved " + pw.Name)
+			default:
+				p.Update(pw.Name + " wasn't changed")
+			}
+		}()
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	store := &PasswordStore{Prefix: t.TempDir()}
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, ".gpg-id"), []byte("alice@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pw := Password{Name: "Mail", Path: filepath.Join(store.Prefix, "Mail.gpg")}
+	original := encryptTest(t, c, "hunter2\n", "alice@example.com")
+	if err := ioutil.WriteFile(pw.Path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := secretFrom([]byte("hunter2\n"))
+	defer plaintext.Wipe()
+
+	// An editor that changes nothing
+	changed, err := store.Edit(pw, plaintext, []string{"true"})
+	if err != nil || changed {
+		t.Fatalf("unchanged edit gave %v, %v", changed, err)
+	}
+
+	changed, err = store.Edit(pw, plaintext, []string{"sed", "-i", "s/hunter2/hunter3/"})
+	if err != nil || !changed {
+		t.Fatalf("edit gave %v, %v", changed, err)
+	}
+	f, err := os.Open(pw.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	edited, err := c.Decrypt(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer edited.Wipe()
+	if got := edited.String(); got != "hunter3\n" {
+		t.Errorf("saved %q", got)
+	}
+
+	// Nothing decrypted is left behind
+	if files, _ := ioutil.ReadDir(os.Getenv("XDG_RUNTIME_DIR")); len(files) != 0 {
+		t.Errorf("left %d files in the edit directory", len(files))
+	}
+
+	if _, err := store.Edit(pw, plaintext, []string{"false"}); err == nil {
+		t.Error("a failing editor didn't fail the edit")
+	}
+}
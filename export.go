@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exportEntry is a decrypted password, as written by the export command
+type exportEntry struct {
+	Name     string        `json:"name"`
+	Password string        `json:"password"`
+	User     string        `json:"user,omitempty"`
+	URL      string        `json:"url,omitempty"`
+	OTP      string        `json:"otp,omitempty"`
+	Fields   []exportField `json:"fields,omitempty"`
+	Notes    string        `json:"notes,omitempty"`
+}
+
+type exportField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// newExportEntry splits a decrypted password file into the password, the
+// fields most password managers know about, other fields and notes
+func newExportEntry(name string, decrypted *Secret) exportEntry {
+	e := exportEntry{Name: name}
+	line := decrypted.Line()
+	e.Password = strings.TrimRight(line.String(), "\r\n")
+	line.Wipe()
+	rest := decrypted.Rest()
+	defer rest.Wipe()
+	var notes []string
+	for _, f := range parseFields(rest.Bytes()) {
+		key, value := string(f.key), string(f.value)
+		switch strings.ToLower(key) {
+		case "user", "username", "login":
+			if e.User == "" {
+				e.User = value
+				continue
+			}
+		case "url", "website":
+			if e.URL == "" {
+				e.URL = value
+				continue
+			}
+		case "otp", "totp":
+			if e.OTP == "" {
+				e.OTP = value
+				continue
+			}
+		case "":
+			notes = append(notes, value)
+			continue
+		}
+		e.Fields = append(e.Fields, exportField{key, value})
+	}
+	e.Notes = strings.Join(notes, "\n")
+	return e
+}
+
+// An exportWriter writes decrypted passwords in some format
+type exportWriter interface {
+	Write(e exportEntry) error
+	Close() error
+}
+
+// jsonExport writes a JSON array, one entry at a time
+type jsonExport struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExport) Write(e exportEntry) error {
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(j.w, "%s%s", sep, data)
+	wipe(data)
+	return err
+}
+
+func (j *jsonExport) Close() error {
+	if j.count == 0 {
+		_, err := fmt.Fprintln(j.w, "[]")
+		return err
+	}
+	_, err := fmt.Fprintln(j.w, "\n]")
+	return err
+}
+
+// csvExport writes the columns most password managers can import, with
+// any other fields added to the notes
+type csvExport struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvExport) Write(e exportEntry) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write([]string{"name", "password", "user", "url", "otp", "notes"}); err != nil {
+			return err
+		}
+	}
+	notes := e.Notes
+	for _, f := range e.Fields {
+		notes += fmt.Sprintf("\n%s: %s", f.Key, f.Value)
+	}
+	return c.w.Write([]string{e.Name, e.Password, e.User, e.URL, e.OTP, strings.TrimSpace(notes)})
+}
+
+func (c *csvExport) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case "json":
+		return &jsonExport{w: w}, nil
+	case "csv":
+		return &csvExport{w: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("Unknown format %s, use json or csv", format)
+}
+
+// Export decrypts every password and writes it to w
+func (ps *PasswordStore) Export(w exportWriter) error {
+	for _, pw := range ps.unique() {
+		decrypted, err := pw.decrypt()
+		if err != nil {
+			return fmt.Errorf("%s: %v", pw.Name, err)
+		}
+		e := newExportEntry(pw.Name, decrypted)
+		decrypted.Wipe()
+		if err := w.Write(e); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// exportCommand writes the decrypted store as JSON or CSV. With --encrypt,
+// the export is encrypted to the keys in the .gpg-id at the top of the store.
+func exportCommand(args []string) error {
+	encrypt := false
+	if len(args) > 0 && args[0] == "--encrypt" {
+		encrypt = true
+		args = args[1:]
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return errUsage
+	}
+	if len(args) == 1 {
+		return export(os.Stdout, args[0], encrypt)
+	}
+	f, err := os.OpenFile(args[1], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = export(f, args[0], encrypt)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Don't leave half of the passwords lying around in plain text
+		os.Remove(args[1])
+	}
+	return err
+}
+
+// export writes the store to out in the given format, encrypted or not
+func export(out io.Writer, format string, encrypt bool) error {
+	if !encrypt {
+		w, err := newExportWriter(format, out)
+		if err != nil {
+			return err
+		}
+		return ps.Export(w)
+	}
+
+	// Encrypting needs the whole export, so it's kept in a Secret meanwhile
+	recipients, err := readGPGID(filepath.Join(ps.Prefix, ".gpg-id"))
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return errors.New("No recipients in .gpg-id")
+	}
+	var plain Secret
+	w, err := newExportWriter(format, &plain)
+	if err != nil {
+		return err
+	}
+	defer plain.Wipe()
+	if err := ps.Export(w); err != nil {
+		return err
+	}
+	ciphertext, err := backend.Encrypt(bytes.NewReader(plain.Bytes()), recipients)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, ciphertext)
+	return err
+}
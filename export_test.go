@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportCommand(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+	defer func(s *PasswordStore) { ps = s }(ps)
+	ps = &PasswordStore{Prefix: t.TempDir()}
+	encrypted := encryptTest(t, c, "hunter2\nlogin: alice\n", "alice@example.com")
+	if err := ioutil.WriteFile(filepath.Join(ps.Prefix, "Mail.gpg"), encrypted, 0600); err != nil {
+		t.Fatal(err)
+	}
+	ps.indexAll()
+
+	out := filepath.Join(t.TempDir(), "export.json")
+	if err := exportCommand([]string{"json", out}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []exportEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Password != "hunter2" || entries[0].User != "alice" {
+		t.Errorf("exported %+v", entries)
+	}
+
+	// An existing file is never overwritten, or removed
+	if err := exportCommand([]string{"json", out}); err == nil {
+		t.Error("overwrote an existing export")
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("existing export is gone: %v", err)
+	}
+
+	// Failing halfway leaves nothing behind
+	if err := ioutil.WriteFile(filepath.Join(ps.Prefix, "Zzz.gpg"), []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ps.indexAll()
+	failed := filepath.Join(t.TempDir(), "failed.json")
+	if err := exportCommand([]string{"json", failed}); err == nil {
+		t.Error("exported a password that can't be decrypted")
+	}
+	if _, err := os.Stat(failed); !os.IsNotExist(err) {
+		t.Errorf("partial export left behind: %v", err)
+	}
+
+	unknown := filepath.Join(t.TempDir(), "export.xml")
+	if err := exportCommand([]string{"xml", unknown}); err == nil {
+		t.Error("exported in an unknown format")
+	}
+	if _, err := os.Stat(unknown); !os.IsNotExist(err) {
+		t.Errorf("empty export left behind: %v", err)
+	}
+}
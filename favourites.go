@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// favouritesFile lists the favourite passwords, one name per line. It is
+// kept in the store, so it's shared along with the passwords.
+const favouritesFile = ".favourites"
+
+// loadFavourites reads which passwords are favourites
+func (ps *PasswordStore) loadFavourites() {
+	favourites := make(map[string]bool)
+	f, err := os.Open(filepath.Join(ps.Prefix, favouritesFile))
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if name := strings.TrimSpace(scanner.Text()); name != "" {
+				favourites[name] = true
+			}
+		}
+	}
+	ps.favourites = favourites
+}
+
+// IsFavourite tells if the named password is a favourite
+func (ps *PasswordStore) IsFavourite(name string) bool {
+	return ps.favourites[name]
+}
+
+// SetFavourite makes the named password a favourite, or not
+func (ps *PasswordStore) SetFavourite(name string, favourite bool) error {
+	ps.loadFavourites()
+	if ps.favourites[name] == favourite {
+		return nil
+	}
+	favourites := make(map[string]bool)
+	for n := range ps.favourites {
+		favourites[n] = true
+	}
+	if favourite {
+		favourites[name] = true
+	} else {
+		delete(favourites, name)
+	}
+	var names []string
+	for n := range favourites {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	content := strings.Join(names, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	path := filepath.Join(ps.Prefix, favouritesFile)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		return err
+	}
+	ps.favourites = favourites
+	if favourite {
+		return ps.gitCommit("Add "+name+" to favourites.", path)
+	}
+	return ps.gitCommit("Remove "+name+" from favourites.", path)
+}
+
+// favouriteRows lists the favourite passwords, which are pinned above
+// the folders
+func (ps *PasswordStore) favouriteRows() []Entry {
+	var rows []Entry
+	for _, p := range ps.passwords {
+		if ps.IsFavourite(p.Name) {
+			rows = append(rows, Entry{Name: p.Name, Path: p.Name, Display: displayName(p.Name, nil, 0, maxDisplayLen),
+				Favourite: true, pw: p})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows
+}
+
+// pinFavourites moves the favourites first, keeping the order otherwise
+func (ps *PasswordStore) pinFavourites(hits []Hit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return ps.IsFavourite(hits[i].Name) && !ps.IsFavourite(hits[j].Name)
+	})
+}
+
+// ToggleFavourite makes the password at a specific index a favourite, or
+// not anymore
+func (p *Passwords) ToggleFavourite(index int) {
+	ui.idle.activity()
+	if index < 0 || index >= len(p.rows) || p.rows[index].IsFolder {
+		ui.setStatus("No password selected")
+		return
+	}
+	name := p.rows[index].Path
+	favourite := !p.store.IsFavourite(name)
+	if err := p.store.SetFavourite(name, favourite); err != nil {
+		ui.setStatus(fmt.Sprintf("Couldn't save favourites: %v", err))
+		return
+	}
+	if favourite {
+		p.Update("Added " + name + " to favourites")
+	} else {
+		p.Update("Removed " + name + " from favourites")
+	}
+}
+
+func favCommand(args []string) error {
+	switch len(args) {
+	case 0:
+		ps.loadFavourites()
+		for _, row := range ps.favouriteRows() {
+			fmt.Println(row.Name)
+		}
+		return nil
+	case 1:
+		return setFavourite(args[0], true)
+	}
+	return errUsage
+}
+
+func unfavCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	return setFavourite(args[0], false)
+}
+
+func setFavourite(name string, favourite bool) error {
+	if _, err := ps.Lookup(name); err != nil {
+		if favourite || !ps.IsFavourite(name) {
+			return err
+		}
+		// A removed password can still be taken off the list
+	}
+	if ps.IsFavourite(name) == favourite {
+		if favourite {
+			return errors.New(name + " is already a favourite")
+		}
+		return errors.New(name + " is not a favourite")
+	}
+	return ps.SetFavourite(name, favourite)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSetFavourite(t *testing.T) {
+	store := &PasswordStore{Prefix: t.TempDir()}
+	store.loadFavourites()
+	if store.IsFavourite("Mail") {
+		t.Error("favourite without a .favourites file")
+	}
+
+	for _, name := range []string{"Work/Git", "Mail", "Bank"} {
+		if err := store.SetFavourite(name, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Setting it again changes nothing
+	if err := store.SetFavourite("Mail", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetFavourite("Bank", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetFavourite("Unknown", false); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"Work/Git": true, "Mail": true, "Bank": false, "Work": false} {
+		if got := store.IsFavourite(name); got != want {
+			t.Errorf("IsFavourite(%q) = %v, want %v", name, got, want)
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(store.Prefix, favouritesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "Mail\nWork/Git\n" {
+		t.Errorf(".favourites is %q", got)
+	}
+
+	// Another instance sees them, and changes made elsewhere aren't lost
+	other := &PasswordStore{Prefix: store.Prefix}
+	other.loadFavourites()
+	if !other.IsFavourite("Work/Git") {
+		t.Error("favourites not saved")
+	}
+	if err := other.SetFavourite("Bank", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetFavourite("Mail", false); err != nil {
+		t.Fatal(err)
+	}
+	if !store.IsFavourite("Bank") || store.IsFavourite("Mail") {
+		t.Error("favourite set by another instance was lost")
+	}
+}
+
+func TestPinFavourites(t *testing.T) {
+	store := &PasswordStore{favourites: map[string]bool{"c": true, "e": true}}
+	var hits []Hit
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		hits = append(hits, Hit{Password: Password{Name: name}})
+	}
+	store.pinFavourites(hits)
+	var names []string
+	for _, h := range hits {
+		names = append(names, h.Name)
+	}
+	if want := []string{"c", "e", "a", "b", "d"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("pinned as %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitCommit commits changed files in the store, like pass does, so git
+// knows when passwords were changed. Stores that aren't git repositories
+// are left alone.
+func (ps *PasswordStore) gitCommit(message string, paths ...string) error {
+	if _, err := os.Stat(filepath.Join(ps.Prefix, ".git")); err != nil {
+		return nil
+	}
+	var rels []string
+	for _, path := range paths {
+		rel, err := filepath.Rel(ps.Prefix, path)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, rel)
+	}
+	if err := ps.git(append([]string{"add", "--"}, rels...)...); err != nil {
+		return err
+	}
+	// Nothing to commit when the files were already like this
+	if ps.git(append([]string{"diff", "--cached", "--quiet", "--"}, rels...)...) == nil {
+		return nil
+	}
+	return ps.git(append([]string{"commit", "-q", "-m", message, "--"}, rels...)...)
+}
+
+// git runs a git command in the store
+func (ps *PasswordStore) git(args ...string) error {
+	out, err := exec.Command("git", append([]string{"-C", ps.Prefix}, args...)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newGitStore makes an empty store that is a git repository
+func newGitStore(t *testing.T) *PasswordStore {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "Test")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "test@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	store := &PasswordStore{Prefix: t.TempDir()}
+	if err := store.git("init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// gitLog lists the subjects of the commits in the store, newest first
+func gitLog(t *testing.T, store *PasswordStore) []string {
+	out, err := exec.Command("git", "-C", store.Prefix, "log", "--format=%s").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n")
+}
+
+func TestGitCommit(t *testing.T) {
+	store := newGitStore(t)
+	path := filepath.Join(store.Prefix, "Mail.gpg")
+	if err := ioutil.WriteFile(path, []byte("one"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.gitCommit("Add Mail.", path); err != nil {
+		t.Fatal(err)
+	}
+	// Unchanged files don't make empty commits
+	if err := store.gitCommit("Nothing.", path); err != nil {
+		t.Fatal(err)
+	}
+	// Other changes aren't committed along
+	other := filepath.Join(store.Prefix, "Other.gpg")
+	if err := ioutil.WriteFile(other, []byte("other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte("two"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.gitCommit("Edit Mail.", path); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(gitLog(t, store), "|"); got != "Edit Mail.|Add Mail." {
+		t.Errorf("commits are %q", got)
+	}
+	if date, err := store.lastChanged(Password{Name: "Mail", Path: path}); err != nil || date.IsZero() {
+		t.Errorf("last changed %v, %v", date, err)
+	}
+	if date, err := store.lastChanged(Password{Name: "Other", Path: other}); err != nil || !date.IsZero() {
+		t.Errorf("uncommitted password last changed %v, %v", date, err)
+	}
+}
+
+func TestGitCommitWithoutRepository(t *testing.T) {
+	store := &PasswordStore{Prefix: t.TempDir()}
+	path := filepath.Join(store.Prefix, "Mail.gpg")
+	if err := ioutil.WriteFile(path, []byte("one"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.gitCommit("Add Mail.", path); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFavouritesCommitted(t *testing.T) {
+	store := newGitStore(t)
+	store.loadFavourites()
+	if err := store.SetFavourite("Mail", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetFavourite("Mail", false); err != nil {
+		t.Fatal(err)
+	}
+	want := "Remove Mail from favourites.|Add Mail to favourites."
+	if got := strings.Join(gitLog(t, store), "|"); got != want {
+		t.Errorf("commits are %q, want %q", got, want)
+	}
+}
+
+func TestImportCommitted(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+	store := newGitStore(t)
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, ".gpg-id"), []byte("alice@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := store.Import(planImport([]importEntry{
+		{Title: "Mail", Password: "a"},
+		{Folder: []string{"Work"}, Title: "Git", Password: "b"},
+	}))
+	if err != nil || imported != 2 {
+		t.Fatalf("imported %d, %v", imported, err)
+	}
+	if got := strings.Join(gitLog(t, store), "|"); got != "Import 2 passwords." {
+		t.Errorf("commits are %q", got)
+	}
+	out, err := exec.Command("git", "-C", store.Prefix, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the .gpg-id, which wasn't written by the import, is left
+	if got := strings.TrimSpace(string(out)); got != "?? .gpg-id" {
+		t.Errorf("git status is %q", got)
+	}
+}
+
+func TestEditCommitted(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	store := newGitStore(t)
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, ".gpg-id"), []byte("alice@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pw := Password{Name: "Mail", Path: filepath.Join(store.Prefix, "Mail.gpg")}
+	plaintext := secretFrom([]byte("hunter2\n"))
+	defer plaintext.Wipe()
+	if _, err := store.Edit(pw, plaintext, []string{"sed", "-i", "s/hunter2/hunter3/"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(gitLog(t, store), "|"); got != "Edit password for Mail using gopass." {
+		t.Errorf("commits are %q", got)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hibpPath is the downloaded Have I Been Pwned password list to check
+// passwords against, set with GOPASS_HIBP. It is either the whole list
+// ordered by hash, or a directory of range files named after the first
+// five characters of the hashes in them.
+func hibpPath() string {
+	return os.Getenv("GOPASS_HIBP")
+}
+
+// pwnedPasswords looks up SHA-1 hashes in a Have I Been Pwned password
+// list on disk, so nothing is sent over the network
+type pwnedPasswords struct {
+	dir  string
+	file *os.File
+	size int64
+}
+
+func openPwnedPasswords(path string) (*pwnedPasswords, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &pwnedPasswords{dir: path}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &pwnedPasswords{file: f, size: info.Size()}, nil
+}
+
+func (p *pwnedPasswords) Close() error {
+	if p.file != nil {
+		return p.file.Close()
+	}
+	return nil
+}
+
+// Count tells how often the password with the given SHA-1 hash was seen
+// in breaches, 0 if never
+func (p *pwnedPasswords) Count(sum [20]byte) (int, error) {
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if p.dir != "" {
+		return p.countInRange(hash)
+	}
+	return p.countInFile(hash)
+}
+
+// countInRange looks for the hash in the range file for its first five
+// characters, which only has the rest of the hashes in it
+func (p *pwnedPasswords) countInRange(hash string) (int, error) {
+	f, err := os.Open(filepath.Join(p.dir, hash[:5]))
+	if os.IsNotExist(err) {
+		f, err = os.Open(filepath.Join(p.dir, hash[:5]+".txt"))
+	}
+	if os.IsNotExist(err) {
+		// No hashes in this range
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if suffix, count := parsePwnedLine(scanner.Text()); suffix == hash[5:] {
+			return count, nil
+		}
+	}
+	return 0, scanner.Err()
+}
+
+// countInFile binary searches the list ordered by hash. lo and hi are
+// the range of offsets where the line with the hash can start.
+func (p *pwnedPasswords) countInFile(hash string) (int, error) {
+	lo, hi := int64(0), p.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := p.lineAt(mid)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if start >= hi || line == "" {
+			hi = mid
+			continue
+		}
+		h, count := parsePwnedLine(line)
+		switch {
+		case h == hash:
+			return count, nil
+		case h < hash:
+			lo = start + int64(len(line))
+		default:
+			hi = start
+		}
+	}
+	return 0, nil
+}
+
+// lineAt reads the first line that starts at or after offset
+func (p *pwnedPasswords) lineAt(offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		start--
+	}
+	r := bufio.NewReader(io.NewSectionReader(p.file, start, p.size-start))
+	if offset > 0 {
+		// Skip to the start of the next line
+		skipped, err := r.ReadString('\n')
+		if err != nil {
+			return p.size, "", err
+		}
+		start += int64(len(skipped))
+	}
+	line, err := r.ReadString('\n')
+	return start, line, err
+}
+
+// parsePwnedLine splits a line like HASH:COUNT
+func parsePwnedLine(line string) (string, int) {
+	parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+	if len(parts) != 2 {
+		return strings.ToUpper(parts[0]), 0
+	}
+	count, _ := strconv.Atoi(parts[1])
+	return strings.ToUpper(parts[0]), count
+}
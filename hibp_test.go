@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// pwnedHashes makes hashes of n passwords, sorted like the list
+func pwnedHashes(n int) [][20]byte {
+	var sums [][20]byte
+	for i := 0; i < n; i++ {
+		sums = append(sums, sha1.Sum([]byte(fmt.Sprintf("password%d", i))))
+	}
+	sort.Slice(sums, func(i, j int) bool { return hexHash(sums[i]) < hexHash(sums[j]) })
+	return sums
+}
+
+func hexHash(sum [20]byte) string {
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writePwnedFile writes the list ordered by hash, with i+1 as the count
+// of the i-th hash
+func writePwnedFile(t *testing.T, sums [][20]byte, newline string, trailing bool) string {
+	var lines []string
+	for i, sum := range sums {
+		lines = append(lines, fmt.Sprintf("%s:%d", hexHash(sum), i+1))
+	}
+	list := strings.Join(lines, newline)
+	if trailing {
+		list += newline
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := ioutil.WriteFile(path, []byte(list), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPwnedPasswordsFile(t *testing.T) {
+	sums := pwnedHashes(100)
+	// The list has every other password, so there are hashes missing
+	// before, after and between the ones in it
+	var listed [][20]byte
+	for i := 1; i < len(sums)-1; i += 2 {
+		listed = append(listed, sums[i])
+	}
+	for _, tc := range []struct {
+		name     string
+		newline  string
+		trailing bool
+	}{
+		{"LF", "\n", true},
+		{"CRLF", "\r\n", true},
+		{"no trailing newline", "\n", false},
+		{"CRLF without trailing newline", "\r\n", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pwned, err := openPwnedPasswords(writePwnedFile(t, listed, tc.newline, tc.trailing))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pwned.Close()
+			for i, sum := range listed {
+				if count, err := pwned.Count(sum); err != nil || count != i+1 {
+					t.Errorf("line %d of %d: count %d, %v, want %d", i+1, len(listed), count, err, i+1)
+				}
+			}
+			for i := 0; i < len(sums); i += 2 {
+				if count, err := pwned.Count(sums[i]); err != nil || count != 0 {
+					t.Errorf("unlisted hash %s: count %d, %v", hexHash(sums[i]), count, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPwnedPasswordsSmallFiles(t *testing.T) {
+	sums := pwnedHashes(3)
+	for n := 0; n <= 2; n++ {
+		pwned, err := openPwnedPasswords(writePwnedFile(t, sums[:n], "\n", false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			if count, _ := pwned.Count(sums[i]); count != i+1 {
+				t.Errorf("%d lines: line %d has count %d", n, i+1, count)
+			}
+		}
+		if count, _ := pwned.Count(sums[2]); count != 0 {
+			t.Errorf("%d lines: unlisted hash has count %d", n, count)
+		}
+		pwned.Close()
+	}
+}
+
+func TestPwnedPasswordsRange(t *testing.T) {
+	dir := t.TempDir()
+	sums := pwnedHashes(3)
+	// One range file as the downloader names it, one with .txt
+	for i, name := range []string{hexHash(sums[0])[:5], hexHash(sums[1])[:5] + ".txt"} {
+		hash := hexHash(sums[i])
+		// The hash to find is the last line, without a newline
+		lines := fmt.Sprintf("0000000000000000000000000000000000A:3\r\n%s:%d", hash[5:], 42+i)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(lines), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pwned, err := openPwnedPasswords(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pwned.Close()
+	for i, want := range []int{42, 43, 0} {
+		if count, err := pwned.Count(sums[i]); err != nil || count != want {
+			t.Errorf("hash %d: count %d, %v, want %d", i, count, err, want)
+		}
+	}
+}
+
+func TestParsePwnedLine(t *testing.T) {
+	for line, want := range map[string]struct {
+		hash  string
+		count int
+	}{
+		"ABC:12\n":   {"ABC", 12},
+		"abc:12\r\n": {"ABC", 12},
+		"ABC":        {"ABC", 0},
+		"ABC:x":      {"ABC", 0},
+	} {
+		if hash, count := parsePwnedLine(line); hash != want.hash || count != want.count {
+			t.Errorf("parsePwnedLine(%q) = %q, %d", line, hash, count)
+		}
+	}
+}
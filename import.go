@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// importEntry is a password read from another password manager
+type importEntry struct {
+	// Folder is where the password goes, one element per directory
+	Folder   []string
+	Title    string
+	Password string
+	// Fields become "key: value" lines after the password
+	Fields []importField
+	Notes  string
+}
+
+type importField struct {
+	Key, Value string
+}
+
+// field adds a field, unless its value is empty
+func (e *importEntry) field(key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		key = strings.Replace(strings.TrimSpace(key), "\n", " ", -1)
+		e.Fields = append(e.Fields, importField{key, value})
+	}
+}
+
+// name is where the entry goes in the store
+func (e importEntry) name() string {
+	var parts []string
+	for _, f := range e.Folder {
+		if f = cleanName(f); f != "" {
+			parts = append(parts, f)
+		}
+	}
+	title := cleanName(e.Title)
+	if title == "" {
+		title = "untitled"
+	}
+	return strings.Join(append(parts, title), "/")
+}
+
+// cleanName makes a folder or title usable as a file name. Leading dots
+// are removed, so it can't be hidden or lead out of the folder it's in.
+func cleanName(s string) string {
+	s = strings.Replace(strings.TrimSpace(s), "/", "-", -1)
+	return strings.TrimLeft(s, ".")
+}
+
+// content is the entry as a pass file: the password on the first line,
+// then the fields and the notes
+func (e importEntry) content() []byte {
+	var b bytes.Buffer
+	b.WriteString(e.Password)
+	b.WriteString("\n")
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "%s: %s\n", f.Key, strings.Replace(f.Value, "\n", " ", -1))
+	}
+	if notes := strings.TrimSpace(e.Notes); notes != "" {
+		b.WriteString(notes)
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// An importer reads the export file of another password manager
+type importer func(path string) ([]importEntry, error)
+
+var importers = map[string]importer{
+	"keepass":   importKeePassXML,
+	"bitwarden": importBitwardenJSON,
+	"1password": import1PasswordCSV,
+	"lastpass":  importLastPassCSV,
+}
+
+// planImport decides what every entry will be called, numbering entries
+// that would get the same name
+func planImport(entries []importEntry) map[string]importEntry {
+	planned := make(map[string]importEntry)
+	for _, e := range entries {
+		name := e.name()
+		for i := 2; ; i++ {
+			if _, taken := planned[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s (%d)", e.name(), i)
+		}
+		planned[name] = e
+	}
+	return planned
+}
+
+// Import encrypts the entries into the store, each to the recipients in
+// the .gpg-id for its folder. Existing passwords are never overwritten.
+// The passwords that were imported are committed together, even when
+// importing stops halfway.
+func (ps *PasswordStore) Import(planned map[string]importEntry) (int, error) {
+	var paths []string
+	err := func() error {
+		for _, name := range sortedNames(planned) {
+			pw := Password{Name: name, Path: filepath.Join(ps.Prefix, name+".gpg")}
+			if !ps.contains(pw.Path) {
+				return fmt.Errorf("%s: Not in the password store", name)
+			}
+			if _, err := os.Stat(pw.Path); err == nil {
+				continue
+			}
+			if err := ps.encryptNew(pw, planned[name].content()); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			paths = append(paths, pw.Path)
+		}
+		return nil
+	}()
+	if len(paths) > 0 {
+		commitErr := ps.gitCommit(fmt.Sprintf("Import %d passwords.", len(paths)), paths...)
+		if err == nil {
+			err = commitErr
+		}
+	}
+	return len(paths), err
+}
+
+// contains tells if path is inside the password store
+func (ps *PasswordStore) contains(path string) bool {
+	rel, err := filepath.Rel(ps.Prefix, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// encryptNew encrypts plaintext to a password file, replacing it if it
+// exists, and wipes it
+func (ps *PasswordStore) encryptNew(pw Password, plaintext []byte) error {
+	defer wipe(plaintext)
+	gpgID, err := ps.gpgIDFile(pw)
+	if err != nil {
+		return err
+	}
+	recipients, err := readGPGID(gpgID)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := backend.Encrypt(bytes.NewReader(plaintext), recipients)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(ciphertext)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(pw.Path), 0700); err != nil {
+		return err
+	}
+	// Write next to the password and rename it over, so the old one stays
+	// if anything goes wrong
+	tmp, err := ioutil.TempFile(filepath.Dir(pw.Path), ".gopass-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), pw.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func sortedNames(planned map[string]importEntry) []string {
+	var names []string
+	for name := range planned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// previewImport prints the tree of passwords an import would create
+func (ps *PasswordStore) previewImport(planned map[string]importEntry) {
+	printed := make(map[string]bool)
+	for _, name := range sortedNames(planned) {
+		parts := strings.Split(name, "/")
+		for i := range parts[:len(parts)-1] {
+			dir := strings.Join(parts[:i+1], "/")
+			if !printed[dir] {
+				printed[dir] = true
+				fmt.Printf("%s%s/\n", strings.Repeat("  ", i), parts[i])
+			}
+		}
+		line := strings.Repeat("  ", len(parts)-1) + parts[len(parts)-1]
+		if _, err := os.Stat(filepath.Join(ps.Prefix, name+".gpg")); err == nil {
+			line += " (exists, skipped)"
+		}
+		fmt.Println(line)
+	}
+}
+
+func importCommand(args []string) error {
+	dryRun := false
+	if len(args) > 0 && (args[0] == "-n" || args[0] == "--dry-run") {
+		dryRun = true
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		return errUsage
+	}
+	read, ok := importers[args[0]]
+	if !ok {
+		var formats []string
+		for f := range importers {
+			formats = append(formats, f)
+		}
+		sort.Strings(formats)
+		return fmt.Errorf("Unknown format %s, use one of %s", args[0], strings.Join(formats, ", "))
+	}
+	entries, err := read(args[1])
+	if err != nil {
+		return err
+	}
+	planned := planImport(entries)
+	if dryRun {
+		ps.previewImport(planned)
+		return nil
+	}
+	imported, err := ps.Import(planned)
+	fmt.Printf("Imported %d of %d passwords\n", imported, len(planned))
+	return err
+}
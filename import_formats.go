@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+)
+
+// KeePass 2 XML export
+type keepassFile struct {
+	Root struct {
+		Group keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keepassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// importKeePassXML reads the XML export of KeePass or KeePassXC. The
+// encrypted KDBX database itself isn't supported, export it first.
+func importKeePassXML(path string) ([]importEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var kp keepassFile
+	if err := xml.NewDecoder(f).Decode(&kp); err != nil {
+		return nil, err
+	}
+	// The root group is named after the database, so leave it out
+	return keepassEntries(kp.Root.Group, nil), nil
+}
+
+func keepassEntries(g keepassGroup, folder []string) []importEntry {
+	var entries []importEntry
+	for _, ke := range g.Entries {
+		e := importEntry{Folder: folder}
+		var custom []importField
+		for _, s := range ke.Strings {
+			switch s.Key {
+			case "Title":
+				e.Title = s.Value
+			case "Password":
+				e.Password = s.Value
+			case "UserName":
+				e.field("user", s.Value)
+			case "URL":
+				e.field("url", s.Value)
+			case "Notes":
+				e.Notes = s.Value
+			case "otp":
+				e.field("otp", s.Value)
+			default:
+				custom = append(custom, importField{s.Key, s.Value})
+			}
+		}
+		for _, c := range custom {
+			e.field(c.Key, c.Value)
+		}
+		entries = append(entries, e)
+	}
+	for _, sub := range g.Groups {
+		subFolder := append(append([]string(nil), folder...), sub.Name)
+		entries = append(entries, keepassEntries(sub, subFolder)...)
+	}
+	return entries
+}
+
+// Bitwarden unencrypted JSON export
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []struct {
+		FolderID string `json:"folderId"`
+		Name     string `json:"name"`
+		Notes    string `json:"notes"`
+		Login    *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			TOTP     string `json:"totp"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	} `json:"items"`
+}
+
+func importBitwardenJSON(path string) ([]importEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var bw bitwardenExport
+	if err := json.NewDecoder(f).Decode(&bw); err != nil {
+		return nil, err
+	}
+	if bw.Encrypted {
+		return nil, errors.New("Encrypted Bitwarden exports aren't supported, export as unencrypted JSON")
+	}
+	folders := make(map[string]string)
+	for _, folder := range bw.Folders {
+		folders[folder.ID] = folder.Name
+	}
+	var entries []importEntry
+	for _, item := range bw.Items {
+		e := importEntry{Title: item.Name, Notes: item.Notes}
+		// Bitwarden nests folders by putting slashes in their names
+		if name := folders[item.FolderID]; name != "" {
+			e.Folder = strings.Split(name, "/")
+		}
+		if item.Login != nil {
+			e.Password = item.Login.Password
+			e.field("user", item.Login.Username)
+			for _, u := range item.Login.URIs {
+				e.field("url", u.URI)
+			}
+			e.field("otp", item.Login.TOTP)
+		}
+		for _, field := range item.Fields {
+			e.field(field.Name, field.Value)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readCSV reads a CSV file with a header, returning a map from lowercase
+// column name to value for every row
+func readCSV(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("Empty CSV file")
+	}
+	header := records[0]
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string)
+		for i, value := range record {
+			if i < len(header) {
+				row[strings.ToLower(strings.TrimSpace(header[i]))] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// pick returns the value of the first of the columns that is set
+func pick(row map[string]string, columns ...string) string {
+	for _, c := range columns {
+		if v := row[c]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// import1PasswordCSV reads the CSV export of 1Password, which has had
+// different column names over the years
+func import1PasswordCSV(path string) ([]importEntry, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []importEntry
+	for _, row := range rows {
+		e := importEntry{
+			Title:    pick(row, "title", "name"),
+			Password: pick(row, "password", "login_password"),
+			Notes:    pick(row, "notes", "notesplain"),
+		}
+		e.field("user", pick(row, "username", "login_username"))
+		e.field("url", pick(row, "url", "website", "login_url", "urls"))
+		e.field("otp", pick(row, "otpauth", "one-time password", "otp"))
+		e.field("tags", pick(row, "tags"))
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// importLastPassCSV reads the CSV export of LastPass
+func importLastPassCSV(path string) ([]importEntry, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []importEntry
+	for _, row := range rows {
+		e := importEntry{
+			Title:    row["name"],
+			Password: row["password"],
+			Notes:    row["extra"],
+		}
+		// LastPass separates subfolders with backslashes
+		if group := row["grouping"]; group != "" {
+			e.Folder = strings.Split(group, "\\")
+		}
+		e.field("user", row["username"])
+		// Secure notes have this as their URL
+		if url := row["url"]; url != "http://sn" {
+			e.field("url", url)
+		}
+		e.field("otp", row["totp"])
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanName(t *testing.T) {
+	for in, want := range map[string]string{
+		"GitHub":      "GitHub",
+		" a/b ":       "a-b",
+		".hidden":     "hidden",
+		".":           "",
+		"..":          "",
+		"...":         "",
+		"../../evil":  "-..-evil",
+		"..../x":      "-x",
+		"version 1.2": "version 1.2",
+	} {
+		if got := cleanName(in); got != want {
+			t.Errorf("cleanName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestImportName(t *testing.T) {
+	for _, tc := range []struct {
+		e    importEntry
+		want string
+	}{
+		{importEntry{Folder: []string{"Work", "Mail"}, Title: "Gmail"}, "Work/Mail/Gmail"},
+		{importEntry{Folder: []string{"..", "..."}, Title: "..."}, "untitled"},
+		{importEntry{Folder: []string{"...", "etc"}, Title: "../passwd"}, "etc/-passwd"},
+	} {
+		if got := tc.e.name(); got != tc.want {
+			t.Errorf("%+v got name %q, want %q", tc.e, got, tc.want)
+		}
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	planned := planImport([]importEntry{
+		{Title: "Mail", Password: "a"},
+		{Title: "Mail", Password: "b"},
+		{Title: "/Mail", Password: "c"},
+	})
+	for name, password := range map[string]string{"Mail": "a", "Mail (2)": "b", "-Mail": "c"} {
+		if planned[name].Password != password {
+			t.Errorf("%s has %q, want %q", name, planned[name].Password, password)
+		}
+	}
+}
+
+func TestStoreContains(t *testing.T) {
+	store := &PasswordStore{Prefix: "/home/user/.password-store"}
+	for path, want := range map[string]bool{
+		"/home/user/.password-store/a.gpg":         true,
+		"/home/user/.password-store/a/..b.gpg":     true,
+		"/home/user/.password-store/../evil.gpg":   false,
+		"/home/user/.password-store-other/a.gpg":   false,
+		"/home/user/.password-store/a/../../x.gpg": false,
+	} {
+		if got := store.contains(path); got != want {
+			t.Errorf("contains(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+
+	parent := t.TempDir()
+	store := &PasswordStore{Prefix: filepath.Join(parent, "store")}
+	os.Mkdir(store.Prefix, 0700)
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, ".gpg-id"), []byte("alice@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(store.Prefix, "Mail.gpg")
+	if err := ioutil.WriteFile(existing, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := store.Import(planImport([]importEntry{
+		{Title: "Mail", Password: "new"},
+		{Folder: []string{"..", ".."}, Title: "../../evil", Password: "x"},
+		{Folder: []string{"Work"}, Title: "Git", Password: "hunter2", Notes: "2FA on"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 {
+		t.Errorf("imported %d, want 2", imported)
+	}
+	if data, _ := ioutil.ReadFile(existing); string(data) != "old" {
+		t.Error("an existing password was overwritten")
+	}
+	if files, _ := filepath.Glob(filepath.Join(parent, "*.gpg")); len(files) != 0 {
+		t.Errorf("import wrote %v outside the store", files)
+	}
+
+	pw := Password{Name: "Work/Git", Path: filepath.Join(store.Prefix, "Work", "Git.gpg")}
+	f, err := os.Open(pw.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	plaintext, err := c.Decrypt(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plaintext.Wipe()
+	if got := plaintext.String(); got != "hunter2\n2FA on\n" {
+		t.Errorf("imported %q", got)
+	}
+}
+
+func TestEncryptNewReplaces(t *testing.T) {
+	_, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+
+	store := &PasswordStore{Prefix: t.TempDir()}
+	pw := Password{Name: "Mail", Path: filepath.Join(store.Prefix, "Mail.gpg")}
+	if err := ioutil.WriteFile(pw.Path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a .gpg-id nothing can be encrypted, and the old file stays
+	if err := store.encryptNew(pw, []byte("new\n")); err == nil {
+		t.Fatal("encrypted without a .gpg-id")
+	}
+	if data, _ := ioutil.ReadFile(pw.Path); string(data) != "old" {
+		t.Errorf("failed encryption left %q", data)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, ".gpg-id"), []byte("alice@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.encryptNew(pw, []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(pw.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	plaintext, err := c.Decrypt(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plaintext.Wipe()
+	if got := plaintext.String(); got != "new\n" {
+		t.Errorf("decrypted %q", got)
+	}
+	if files, _ := ioutil.ReadDir(store.Prefix); len(files) != 2 {
+		t.Errorf("left %d files in the store, want the password and .gpg-id", len(files))
+	}
+}
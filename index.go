@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexEnabled tells if metadata is indexed for queries, which is turned
+// on with GOPASS_INDEX=1
+func indexEnabled() bool {
+	return os.Getenv("GOPASS_INDEX") == "1"
+}
+
+// indexPath is where the index of the store in prefix is cached
+func indexPath(prefix string) string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir(), ".cache")
+	}
+	sum := sha256.Sum256([]byte(prefix))
+	return filepath.Join(dir, "gopass", "index-"+hex.EncodeToString(sum[:8])+".gpg")
+}
+
+// indexedKeys are the metadata keys whose values are kept in the index, and
+// the field they are kept as. Only the names of other keys are kept.
+var indexedKeys = map[string]string{
+	"user":     "user",
+	"username": "user",
+	"login":    "user",
+	"email":    "user",
+	"url":      "url",
+	"website":  "url",
+	"site":     "url",
+	"tags":     "tags",
+	"tag":      "tags",
+}
+
+// indexFields picks what to index from the metadata of a password
+func indexFields(metadata []byte) map[string]string {
+	fields := make(map[string]string)
+	for _, f := range parseFields(metadata) {
+		if bytes.HasPrefix(f.value, []byte("otpauth://")) {
+			fields["otp"] = ""
+		}
+		key := strings.ToLower(string(f.key))
+		if key == "" {
+			continue
+		}
+		fields[key] = ""
+		if to, ok := indexedKeys[key]; ok && !f.secret {
+			fields[to] = string(f.value)
+		}
+	}
+	return fields
+}
+
+// indexEntry is what the index knows about a password
+type indexEntry struct {
+	// ModTime and Size tell if the password changed since it was indexed
+	ModTime time.Time
+	Size    int64
+	Fields  map[string]string
+}
+
+// fileIndex is a metadataIndex cached in a file, encrypted to the user's
+// own key
+type fileIndex struct {
+	mu      sync.RWMutex
+	path    string
+	entries map[string]indexEntry
+}
+
+// Lookup returns the indexed fields of the named password
+func (idx *fileIndex) Lookup(name string) map[string]string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.entries[name].Fields
+}
+
+// load reads the cached index, starting with an empty one if there is none
+func (idx *fileIndex) load() error {
+	f, err := os.Open(idx.path)
+	if os.IsNotExist(err) {
+		idx.entries = make(map[string]indexEntry)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	decrypted, err := backend.Decrypt(f)
+	if err != nil {
+		return err
+	}
+	defer decrypted.Wipe()
+	entries := make(map[string]indexEntry)
+	if err := json.Unmarshal(decrypted.Bytes(), &entries); err != nil {
+		return err
+	}
+	idx.mu.Lock()
+	idx.entries = entries
+	idx.mu.Unlock()
+	return nil
+}
+
+// save encrypts the index to recipients and writes it to the cache
+func (idx *fileIndex) save(recipients []string) error {
+	idx.mu.RLock()
+	data, err := json.Marshal(idx.entries)
+	idx.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	defer wipe(data)
+	ciphertext, err := backend.Encrypt(bytes.NewReader(data), recipients)
+	if err != nil {
+		return err
+	}
+	encrypted, err := ioutil.ReadAll(ciphertext)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0700); err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, encrypted, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.path)
+}
+
+// refresh indexes the passwords that changed since they were indexed, and
+// forgets the ones that are gone. It returns how many were indexed.
+func (idx *fileIndex) refresh(passwords []Password) (int, error) {
+	seen := make(map[string]bool)
+	indexed := 0
+	for _, p := range passwords {
+		seen[p.Name] = true
+		info, err := os.Stat(p.Path)
+		if err != nil {
+			continue
+		}
+		idx.mu.RLock()
+		e, ok := idx.entries[p.Name]
+		idx.mu.RUnlock()
+		if ok && e.ModTime.Equal(info.ModTime()) && e.Size == info.Size() {
+			continue
+		}
+		e = indexEntry{ModTime: info.ModTime(), Size: info.Size()}
+		metadata, err := p.Metadata()
+		switch {
+		case err == errCancelled:
+			return indexed, err
+		case err == nil:
+			e.Fields = indexFields(metadata.Bytes())
+			metadata.Wipe()
+		}
+		// Passwords that can't be decrypted are indexed without fields,
+		// so they aren't tried again until they change
+		idx.mu.Lock()
+		idx.entries[p.Name] = e
+		idx.mu.Unlock()
+		indexed++
+	}
+
+	idx.mu.Lock()
+	for name := range idx.entries {
+		if !seen[name] {
+			delete(idx.entries, name)
+			indexed++
+		}
+	}
+	idx.mu.Unlock()
+	return indexed, nil
+}
+
+// startIndex loads the metadata index, and keeps it up to date in the
+// background whenever passwords change
+func (ps *PasswordStore) startIndex() {
+	idx := &fileIndex{path: indexPath(ps.Prefix)}
+	ps.index = idx
+	ps.reindex = make(chan bool, 1)
+	go func() {
+		for range ps.reindex {
+			if err := ps.refreshIndex(idx); err != nil {
+				ps.publishUpdate(fmt.Sprintf("Couldn't update the metadata index: %v", err))
+			}
+		}
+	}()
+	ps.requestReindex()
+}
+
+// requestReindex has the index refreshed, unless it's not used
+func (ps *PasswordStore) requestReindex() {
+	if ps.reindex == nil {
+		return
+	}
+	select {
+	case ps.reindex <- true:
+	default:
+		// A refresh is already waiting, and will see this change too
+	}
+}
+
+func (ps *PasswordStore) refreshIndex(idx *fileIndex) error {
+	if idx.entries == nil {
+		if err := idx.load(); err != nil {
+			return err
+		}
+	}
+	indexed, err := idx.refresh(ps.passwords)
+	if indexed == 0 {
+		return err
+	}
+	// Whatever was indexed before being cancelled is still kept
+	key, saveErr := ps.ownKey()
+	if saveErr == nil {
+		saveErr = idx.save([]string{key})
+	}
+	if saveErr != nil {
+		return saveErr
+	}
+	ps.publishUpdate(fmt.Sprintf("Indexed metadata of %d entries", indexed))
+	return err
+}
+
+// ownKey finds the user's own key, to encrypt the index to. It's the first
+// key the passwords are encrypted to that there is a secret key for, since
+// that's the key that decrypted them. Other people who share the store
+// can't read the index.
+func (ps *PasswordStore) ownKey() (string, error) {
+	for _, p := range ps.passwords {
+		keyIDs, err := p.KeyIDs()
+		if err != nil {
+			continue
+		}
+		for _, id := range keyIDs {
+			if ki, err := backend.KeyInfo(id); err == nil && ki.Secret {
+				return fmt.Sprintf("0x%016X", id), nil
+			}
+		}
+	}
+	return "", errors.New("No secret key to encrypt the index to")
+}
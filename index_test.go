@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexFields(t *testing.T) {
+	fields := indexFields([]byte("user: alice\nurl: https://example.com\npin: 1234\ntotp: otpauth://totp/x?secret=ABC\n"))
+	for key, want := range map[string]string{"user": "alice", "url": "https://example.com", "pin": "", "otp": ""} {
+		if got, ok := fields[key]; !ok || got != want {
+			t.Errorf("%s is %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRefreshIndex(t *testing.T) {
+	alice, _, c := newSharedTestCrypto(t)
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+
+	// The store is shared with Bob, and has no .gpg-id at the top
+	store := &PasswordStore{Prefix: t.TempDir()}
+	os.Mkdir(filepath.Join(store.Prefix, "Work"), 0700)
+	pw := Password{Name: "Work/Git", Path: filepath.Join(store.Prefix, "Work", "Git.gpg")}
+	ciphertext := encryptTest(t, c, "hunter2\nuser: alice\n", "bob@example.com", "alice@example.com")
+	if err := ioutil.WriteFile(pw.Path, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+	store.passwords = []Password{pw}
+
+	idx := &fileIndex{path: filepath.Join(t.TempDir(), "index.gpg")}
+	if err := store.refreshIndex(idx); err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Lookup("Work/Git")["user"]; got != "alice" {
+		t.Errorf("indexed user %q", got)
+	}
+
+	// Only Alice can read her index
+	f, err := os.Open(idx.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	keyIDs, err := c.RecipientsOf(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := alice.Subkeys[0].PublicKey.KeyId; len(keyIDs) != 1 || keyIDs[0] != want {
+		t.Errorf("index is encrypted to %X, want only %X", keyIDs, want)
+	}
+
+	loaded := &fileIndex{path: idx.path}
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Lookup("Work/Git")["user"]; got != "alice" {
+		t.Errorf("loaded user %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/limetext/qml-go"
+)
+
+// instanceSocket is where the running instance listens for other
+// instances asking it to show its window. Without XDG_RUNTIME_DIR it goes
+// in a directory of our own in /tmp, since anyone could have created a
+// socket at a predictable path there.
+func instanceSocket() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gopass.sock"), nil
+	}
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("gopass-%d", os.Getuid()))
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := checkPrivate(dir, os.ModeDir); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gopass.sock"), nil
+}
+
+// checkPrivate makes sure a file is of the given type, belongs to us and
+// nobody else can use it
+func checkPrivate(path string, mode os.FileMode) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	switch {
+	case fi.Mode().Type() != mode:
+		return fmt.Errorf("%s is not a %s", path, typeName(mode))
+	case !ok || int(stat.Uid) != os.Getuid():
+		return fmt.Errorf("%s belongs to someone else", path)
+	case fi.Mode().Perm()&0077 != 0:
+		return fmt.Errorf("%s can be used by others", path)
+	}
+	return nil
+}
+
+func typeName(mode os.FileMode) string {
+	if mode == os.ModeDir {
+		return "directory"
+	}
+	return "socket"
+}
+
+// dialInstance connects to the running instance, if the socket is one we
+// created ourselves
+func dialInstance() (net.Conn, error) {
+	path, err := instanceSocket()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPrivate(path, os.ModeSocket); err != nil {
+		return nil, err
+	}
+	return net.Dial("unix", path)
+}
+
+// removeInstanceSocket cleans up the socket when exiting
+func removeInstanceSocket() {
+	if path, err := instanceSocket(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// activateRunning asks an already running instance to show its window,
+// returning false if there is none
+func activateRunning() bool {
+	conn, err := dialInstance()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	_, err = fmt.Fprintln(conn, "show")
+	return err == nil
+}
+
+// listenForActivation shows the window whenever another instance asks for it
+func listenForActivation() error {
+	path, err := instanceSocket()
+	if err != nil {
+		return err
+	}
+	// Nobody answered in activateRunning, so the socket is left over
+	// from an instance that didn't exit cleanly
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	// Only we may connect, whatever the umask is
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+			if strings.TrimSpace(line) == "show" {
+				qml.RunMain(ui.Activate)
+			}
+		}
+	}()
+	return nil
+}
+
+// Activate shows and focuses the window, with an empty search box
+func (ui *UI) Activate() {
+	if window == nil {
+		return
+	}
+	ui.idle.activity()
+	window.Call("activate")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstanceSocketRuntimeDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	path, err := instanceSocket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "gopass.sock"); path != want {
+		t.Errorf("socket at %s, want %s", path, want)
+	}
+}
+
+func TestInstanceSocketTempDir(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("TMPDIR", tmp)
+	private := filepath.Join(tmp, fmt.Sprintf("gopass-%d", os.Getuid()))
+
+	path, err := instanceSocket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != private {
+		t.Errorf("socket at %s, want it in %s", path, private)
+	}
+	fi, err := os.Stat(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0700 {
+		t.Errorf("directory has mode %o, want 700", perm)
+	}
+
+	// Someone else could have put things in a directory others can write to
+	if err := os.Chmod(private, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instanceSocket(); err == nil {
+		t.Error("directory writable by others was used")
+	}
+
+	// Or made it a link to somewhere they control
+	if err := os.Remove(private); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), private); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instanceSocket(); err == nil {
+		t.Error("symlink was followed")
+	}
+}
+
+func TestDialInstance(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	path := filepath.Join(dir, "gopass.sock")
+
+	if _, err := dialInstance(); err == nil {
+		t.Error("connected without a running instance")
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := os.Chmod(path, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dialInstance(); err == nil {
+		t.Error("connected to a socket others can use")
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := dialInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
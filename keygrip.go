@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// Algorithms not supported by golang.org/x/crypto/openpgp, that gpg
+// still uses or used to use
+const (
+	pubKeyAlgoElGamalSign packet.PublicKeyAlgorithm = 20
+	pubKeyAlgoEdDSA       packet.PublicKeyAlgorithm = 22
+)
+
+// Packet tags for keys, see RFC 4880 section 4.3
+const (
+	tagSecretKey    = 5
+	tagPublicKey    = 6
+	tagSecretSubkey = 7
+	tagUserID       = 13
+	tagPublicSubkey = 14
+)
+
+// pubKey is a key packet parsed just far enough to identify it and
+// compute its keygrip. Unlike golang.org/x/crypto/openpgp, this
+// understands every kind of key gpg produces.
+type pubKey struct {
+	KeyID       uint64
+	Fingerprint [20]byte
+	Algo        packet.PublicKeyAlgorithm
+	Curve       *curve
+	// params are the public key parameters as stored in the packet,
+	// for example n and e for RSA or q for elliptic curves
+	params [][]byte
+	bits   []uint16
+
+	// primary is the primary key of a subkey, nil for primary keys
+	primary *pubKey
+	userIDs []string
+}
+
+// readPubKeys reads all key and subkey packets in a keyring, along with
+// the user IDs of each key, skipping keys that can't be parsed
+func readPubKeys(r io.Reader) ([]*pubKey, error) {
+	var keys []*pubKey
+	var primary *pubKey
+	packets := packet.NewOpaqueReader(r)
+	for {
+		p, err := packets.Next()
+		if err == io.EOF {
+			return keys, nil
+		}
+		if err != nil {
+			return keys, err
+		}
+		switch p.Tag {
+		case tagPublicKey, tagSecretKey:
+			primary = nil
+			if k, err := parsePubKey(p.Contents); err == nil {
+				primary = k
+				keys = append(keys, k)
+			}
+		case tagPublicSubkey, tagSecretSubkey:
+			if k, err := parsePubKey(p.Contents); err == nil && primary != nil {
+				k.primary = primary
+				keys = append(keys, k)
+			}
+		case tagUserID:
+			if primary != nil {
+				primary.userIDs = append(primary.userIDs, string(p.Contents))
+			}
+		}
+	}
+}
+
+// parsePubKey parses the public part of a version 4 key packet
+func parsePubKey(contents []byte) (*pubKey, error) {
+	r := bytes.NewReader(contents)
+	var header [6]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != 4 {
+		return nil, fmt.Errorf("unsupported key version %d", header[0])
+	}
+	k := &pubKey{Algo: packet.PublicKeyAlgorithm(header[5])}
+
+	var nParams int
+	switch k.Algo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoRSASignOnly:
+		nParams = 2 // n, e
+	case packet.PubKeyAlgoDSA:
+		nParams = 4 // p, q, g, y
+	case packet.PubKeyAlgoElGamal, pubKeyAlgoElGamalSign:
+		nParams = 3 // p, g, y
+	case packet.PubKeyAlgoECDH, packet.PubKeyAlgoECDSA, pubKeyAlgoEdDSA:
+		oid, err := readOID(r)
+		if err != nil {
+			return nil, err
+		}
+		if k.Curve = curveByOID(oid); k.Curve == nil {
+			return nil, fmt.Errorf("unsupported curve %X", oid)
+		}
+		nParams = 1 // q
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm %d", k.Algo)
+	}
+	for i := 0; i < nParams; i++ {
+		param, bits, err := readMPI(r)
+		if err != nil {
+			return nil, err
+		}
+		k.params = append(k.params, param)
+		k.bits = append(k.bits, bits)
+	}
+	if k.Algo == packet.PubKeyAlgoECDH {
+		// Skip the KDF parameters
+		if _, err := readOID(r); err != nil {
+			return nil, err
+		}
+	}
+
+	// The fingerprint covers the public part of the packet only
+	public := contents[:len(contents)-r.Len()]
+	h := sha1.New()
+	h.Write([]byte{0x99, byte(len(public) >> 8), byte(len(public))})
+	h.Write(public)
+	copy(k.Fingerprint[:], h.Sum(nil))
+	k.KeyID = binary.BigEndian.Uint64(k.Fingerprint[12:20])
+	return k, nil
+}
+
+// readOID reads a length prefixed field, as used for curve OIDs and KDF parameters
+func readOID(r io.Reader) ([]byte, error) {
+	var l [1]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, err
+	}
+	oid := make([]byte, l[0])
+	_, err := io.ReadFull(r, oid)
+	return oid, err
+}
+
+func readMPI(r io.Reader) ([]byte, uint16, error) {
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, 0, err
+	}
+	bits := binary.BigEndian.Uint16(l[:])
+	mpi := make([]byte, (int(bits)+7)/8)
+	_, err := io.ReadFull(r, mpi)
+	return mpi, bits, err
+}
+
+// Primary returns the primary key for subkeys, and the key itself otherwise
+func (k *pubKey) Primary() *pubKey {
+	if k.primary != nil {
+		return k.primary
+	}
+	return k
+}
+
+// UserIDs of the key, which for subkeys are those of its primary key
+func (k *pubKey) UserIDs() []string {
+	return k.Primary().userIDs
+}
+
+// BitLength of the key, which for elliptic curves is the size of the curve
+func (k *pubKey) BitLength() uint16 {
+	if k.Curve != nil {
+		return k.Curve.bitLength
+	}
+	return k.bits[0]
+}
+
+// KeyIdShortString is the short, 8 character hex key ID
+func (k *pubKey) KeyIdShortString() string {
+	return fmt.Sprintf("%X", k.Fingerprint[16:20])
+}
+
+// AlgorithmName describes the algorithm, including the curve if there is one
+func (k *pubKey) AlgorithmName() string {
+	if k.Curve != nil {
+		return algoString(k.Algo) + " " + k.Curve.name
+	}
+	return algoString(k.Algo)
+}
+
+// Keygrip computes the keygrip gpg-agent uses to identify the key, the
+// same way libgcrypt does in gcry_pk_get_keygrip
+func (k *pubKey) Keygrip() (string, error) {
+	h := sha1.New()
+	switch k.Algo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoRSASignOnly:
+		// RSA only hashes n, without any S-expression around it
+		h.Write(signedMPI(k.params[0]))
+	case packet.PubKeyAlgoDSA:
+		hashParams(h, "pqgy", k.params)
+	case packet.PubKeyAlgoElGamal, pubKeyAlgoElGamalSign:
+		hashParams(h, "pgy", k.params)
+	case packet.PubKeyAlgoECDH, packet.PubKeyAlgoECDSA, pubKeyAlgoEdDSA:
+		q := k.params[0]
+		if len(q) > 0 && q[0] == 0x40 {
+			// Ed25519 and Curve25519 points are hashed without their
+			// native point format prefix
+			q = q[1:]
+		}
+		c := k.Curve
+		for _, p := range []struct {
+			name  byte
+			value []byte
+		}{
+			{'p', c.p}, {'a', c.a}, {'b', c.b}, {'g', c.g}, {'n', c.n}, {'q', q},
+		} {
+			hashParam(h, p.name, p.value)
+		}
+	default:
+		return "", errors.New("Unknown crypto")
+	}
+	return fmt.Sprintf("%X", h.Sum(nil)), nil
+}
+
+// hashParams hashes each parameter as the S-expression (1:<name><length>:<value>)
+func hashParams(h io.Writer, names string, params [][]byte) {
+	for i := range names {
+		hashParam(h, names[i], signedMPI(params[i]))
+	}
+}
+
+func hashParam(h io.Writer, name byte, value []byte) {
+	fmt.Fprintf(h, "(1:%c%d:", name, len(value))
+	h.Write(value)
+	h.Write([]byte(")"))
+}
+
+// signedMPI prefixes an MPI with a zero byte when the high bit is set,
+// so it is not taken to be negative, the way libgcrypt stores it
+func signedMPI(mpi []byte) []byte {
+	if len(mpi) > 0 && mpi[0]&0x80 != 0 {
+		return append([]byte{0}, mpi...)
+	}
+	return mpi
+}
+
+// curve holds the domain parameters libgcrypt hashes into keygrips
+type curve struct {
+	name       string
+	oid        []byte
+	bitLength  uint16
+	p, a, b, g []byte
+	n          []byte
+}
+
+var curves []*curve
+
+func init() {
+	curves = []*curve{
+		nistCurve("NIST P-256", []byte{0x2A, 0x86, 0x48, 0xCE, 0x3D, 0x03, 0x01, 0x07}, elliptic.P256()),
+		nistCurve("NIST P-384", []byte{0x2B, 0x81, 0x04, 0x00, 0x22}, elliptic.P384()),
+		nistCurve("NIST P-521", []byte{0x2B, 0x81, 0x04, 0x00, 0x23}, elliptic.P521()),
+		{
+			name:      "brainpoolP256r1",
+			oid:       []byte{0x2B, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x07},
+			bitLength: 256,
+			p:         hexBytes("A9FB57DBA1EEA9BC3E660A909D838D726E3BF623D52620282013481D1F6E5377"),
+			a:         hexBytes("7D5A0975FC2C3057EEF67530417AFFE7FB8055C126DC5C6CE94A4B44F330B5D9"),
+			b:         hexBytes("26DC5C6CE94A4B44F330B5D9BBD77CBF958416295CF7E1CE6BCCDC18FF8C07B6"),
+			g: hexBytes("04" +
+				"8BD2AEB9CB7E57CB2C4B482FFC81B7AFB9DE27E1E3BD23C23A4453BD9ACE3262" +
+				"547EF835C3DAC4FD97F8461A14611DC9C27745132DED8E545C1D54C72F046997"),
+			n: hexBytes("A9FB57DBA1EEA9BC3E660A909D838D718C397AA3B561A6F7901E0E82974856A7"),
+		},
+		{
+			name:      "brainpoolP384r1",
+			oid:       []byte{0x2B, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x0B},
+			bitLength: 384,
+			p: hexBytes("8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B4" +
+				"12B1DA197FB71123ACD3A729901D1A71874700133107EC53"),
+			a: hexBytes("7BC382C63D8C150C3C72080ACE05AFA0C2BEA28E4FB22787" +
+				"139165EFBA91F90F8AA5814A503AD4EB04A8C7DD22CE2826"),
+			b: hexBytes("04A8C7DD22CE28268B39B55416F0447C2FB77DE107DCD2A6" +
+				"2E880EA53EEB62D57CB4390295DBC9943AB78696FA504C11"),
+			g: hexBytes("04" +
+				"1D1C64F068CF45FFA2A63A81B7C13F6B8847A3E77EF14FE3" +
+				"DB7FCAFE0CBD10E8E826E03436D646AAEF87B2E247D4AF1E" +
+				"8ABE1D7520F9C2A45CB1EB8E95CFD55262B70B29FEEC5864" +
+				"E19C054FF99129280E4646217791811142820341263C5315"),
+			n: hexBytes("8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B3" +
+				"1F166E6CAC0425A7CF3AB6AF6B7FC3103B883202E9046565"),
+		},
+		{
+			name:      "brainpoolP512r1",
+			oid:       []byte{0x2B, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x0D},
+			bitLength: 512,
+			p: hexBytes("AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA70330871" +
+				"7D4D9B009BC66842AECDA12AE6A380E62881FF2F2D82C68528AA6056583A48F3"),
+			a: hexBytes("7830A3318B603B89E2327145AC234CC594CBDD8D3DF91610A83441CAEA9863BC" +
+				"2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A72BF2C7B9E7C1AC4D77FC94CA"),
+			b: hexBytes("3DF91610A83441CAEA9863BC2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A7" +
+				"2BF2C7B9E7C1AC4D77FC94CADC083E67984050B75EBAE5DD2809BD638016F723"),
+			g: hexBytes("04" +
+				"81AEE4BDD82ED9645A21322E9C4C6A9385ED9F70B5D916C1B43B62EEF4D0098E" +
+				"FF3B1F78E2D0D48D50D1687B93B97D5F7C6D5047406A5E688B352209BCB9F822" +
+				"7DDE385D566332ECC0EABFA9CF7822FDF209F70024A57B1AA000C55B881F8111" +
+				"B2DCDE494A5F485E5BCA4BD88A2763AED1CA2B2FA8F0540678CD1E0F3AD80892"),
+			n: hexBytes("AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA70330870" +
+				"553E5C414CA92619418661197FAC10471DB1D381085DDADDB58796829CA90069"),
+		},
+		{
+			name:      "secp256k1",
+			oid:       []byte{0x2B, 0x81, 0x04, 0x00, 0x0A},
+			bitLength: 256,
+			p:         hexBytes("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F"),
+			// a is zero, which libgcrypt hashes as an empty value
+			a: nil,
+			b: hexBytes("07"),
+			g: hexBytes("04" +
+				"79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798" +
+				"483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8"),
+			n: hexBytes("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141"),
+		},
+		{
+			name:      "Ed25519",
+			oid:       []byte{0x2B, 0x06, 0x01, 0x04, 0x01, 0xDA, 0x47, 0x0F, 0x01},
+			bitLength: 255,
+			p:         hexBytes("7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED"),
+			// libgcrypt stores a and b as negative numbers, and hashes their magnitude
+			a: hexBytes("01"),
+			b: hexBytes("2DFC9311D490018C7338BF8688861767FF8FF5B2BEBE27548A14B235ECA6874A"),
+			g: hexBytes("04" +
+				"216936D3CD6E53FEC0A4E231FDD6DC5C692CC7609525A7B2C9562D608F25D51A" +
+				"6666666666666666666666666666666666666666666666666666666666666658"),
+			n: hexBytes("1000000000000000000000000000000014DEF9DEA2F79CD65812631A5CF5D3ED"),
+		},
+		{
+			name:      "Curve25519",
+			oid:       []byte{0x2B, 0x06, 0x01, 0x04, 0x01, 0x97, 0x55, 0x01, 0x05, 0x01},
+			bitLength: 255,
+			p:         hexBytes("7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED"),
+			a:         hexBytes("01DB41"),
+			b:         hexBytes("01"),
+			g: hexBytes("04" +
+				"0000000000000000000000000000000000000000000000000000000000000009" +
+				"20AE19A1B8A086B4E01EDD2C7748D14C923D4D7E6D7C61B229E9C5A27ECED3D9"),
+			n: hexBytes("1000000000000000000000000000000014DEF9DEA2F79CD65812631A5CF5D3ED"),
+		},
+	}
+}
+
+// nistCurve gets the domain parameters for a NIST curve from crypto/elliptic
+func nistCurve(name string, oid []byte, c elliptic.Curve) *curve {
+	params := c.Params()
+	size := (params.BitSize + 7) / 8
+	g := []byte{4}
+	g = append(g, padBytes(params.Gx, size)...)
+	g = append(g, padBytes(params.Gy, size)...)
+	return &curve{
+		name:      name,
+		oid:       oid,
+		bitLength: uint16(params.BitSize),
+		p:         params.P.Bytes(),
+		// All NIST curves have a = -3
+		a: new(big.Int).Sub(params.P, big.NewInt(3)).Bytes(),
+		b: params.B.Bytes(),
+		g: g,
+		n: params.N.Bytes(),
+	}
+}
+
+func curveByOID(oid []byte) *curve {
+	for _, c := range curves {
+		if bytes.Equal(c.oid, oid) {
+			return c
+		}
+	}
+	return nil
+}
+
+func padBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func hexBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
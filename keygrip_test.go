@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// keygripTests are key packets exported by gpg 2.2, with the keygrips
+// gpg --with-keygrip shows for them
+var keygripTests = []struct {
+	name, packet, keygrip string
+}{
+	{"RSA", "BGrV1U0BBACY2mHexTvD06TEElB0Wb/k/soYI+UODXAVgh2Qakbs/dXuJD7w/6+srp5YlmsRPUbpejJ3sA/AdNqu3/8T1KDzXo9aNfdIhE1+qtvSCqEfsGv66H3VvWMx9h/o40zwS0aX+7dYz5ruQZ3AjFRyMa4tI0wSaRVMsYejf2Qgoa9TKwARAQAB",
+		"22DC9A3A0AFA6C9FCDEB8C91AC03FAC85C1D1062"},
+	{"DSA", "BGrV1U0RBACUbIITSpBmAXFVIctVanTbf/+TOGvR6Z3UGCNFfq+7HiSkHeP5UOX8QMDixj0Gmx86+Fz/KgJHBPrlWbsWEqgfOfe3jf3RYKtGYP78ht0/PdrFs/WO5JIX3ihC95sx5Jq0fGnx3K0ujhuVoyVHKh7x+B37OkgKhLgSxARVYqY6QwCgwNRsXf1Ww/rMnqpimcFv7WMnnycD/AqGKZQHzTMxAX3mJC36i4FRUvwis2jbbe4PCmzE1qltvtWfhsIMDht0i7jd6xyc/wG8nXPjH2cDwzAB/OgQlzG6Ui1xtjQUIAiQccIE/q7bYtxdNCWjnJbA4hR8b2J34se8HcS5VeS6jQ6LshN8o/w4Jsx0YlDJElJMhnmeUeNYA/9st+8uKWwOk1ymKENfx3lFfDkR1/2RBaSQ4CLnSDluUWU/l5N3M9dehPVORo5b2bfuNvDQ/6KsIOifsYyw4/k2JV5wx3kbn6mIUAyye4sKdUGnbCCoJHotkNchLtzfOVoEiX1WZHLonkbUkgfyQgQcwd7iKdg1jyBRyC986A+YHQ==",
+		"248ECC86269F70AC6F7100FD9CB18FB1A902313C"},
+	{"ElGamal", "BGrV1VEQBACZhL0bbfI55hDZv5EhxWHe5acqBKCnv8Uz1Mpb0agPdVMhhavRSUWHNqp4Ycqtzu91R+80py31TW5uVGcxf48X0li5YGNUN6azXRiN9H1JVWEiy+gPopNNIuOgX2krZZmEi0TYaUv2qT5CFpo8dqVss3o2yCS4Vv4xr2dz3CFGkwADBQP8ChlnsDLwT+k98gOdl8FeZtasC7ji2nYXRhBrcWC2PhQ86xfY9BluBAmPz+JEgYMSU+C8N1JxZJBTdQ7W2jrTBxmZPeb11PDpB5LaPDO3gvI/SxMMH9DADQFoFhaTml46W0qfhw87puDchIlAe+NXaAR+MECmykmvCrvwWt5jygc=",
+		"FFF2B025B5A283FA2AF7137AFB40C1EA3869A947"},
+	{"ECDSA P-256", "BGrV1U4TCCqGSM49AwEHAgMEXQoBbWHbwF3cLdxo26Gf5uO3pTfkd4+f9jpC7r9ze7dYSxJa1zsJFPNw+5CQ+HTtTkg0pOR9+iz/3HbS9MvoKw==",
+		"4B06BF79067C2F274FF2C2C0F6114EA05F87392B"},
+	{"ECDH P-256", "BGrV1VISCCqGSM49AwEHAgMEFOEU1zn90JJqu89kjkKsvEfeVQMuuJT6jN1Uqsosf9E96Nb4PQxdQyNsoQxKHUXFED7NxkIzsiTasSC4X9PrEgMBCAc=",
+		"CE2374F82D68630E7F4E283AAF9899D4556F99AA"},
+	{"ECDSA P-384", "BGrV1U4TBSuBBAAiAwMEFHiFWk2nNK5j30XclLKKD2QDde+ins8AykSOvIGRHzkCABM6CGMzgC6ypLSqWwA/LPkafZwQGvpoRwwQwx9XgLM/McHqG8J3lhUu78hkcM1n0Labuj3u36oOYgb/asO9",
+		"2B7C4BABCBA728D7544B8961CAECBC849AD09784"},
+	{"ECDSA P-521", "BGrV1U4TBSuBBAAjBCMEAElj36FNiob4RTrOObG4IF4N4SKdIJZ7qoFI1IqF4PwohSb9Wq26JW2RX/HB5wDHnSX3sy/OGCPFB2aqESZ2ORxFAC3dv1OFsocEKegU72H1NJIdPIBx5nm4z1hS1b4MH/l0zdGwfixoZnpOg4voWaUd7tkAHJ8RLhu8jFo1tKH3GaIb",
+		"163C1866C0BAB03209983032B75CE50BF89C3A50"},
+	{"EdDSA Ed25519", "BGrV1U4WCSsGAQQB2kcPAQEHQFq1YRp+LKZwaCoNs1fqR+3CJhrBDdhetUAOppYazTxv",
+		"A9A4E56D59C3A33FB9F3792AD93839EAE0939E3E"},
+	{"ECDH Curve25519", "BGrV1VISCisGAQQBl1UBBQEBB0CdhdWvARrX1jeC7laPja0GdxUuy2wowHFdBfDTHBXvPAMBCAc=",
+		"40D8B40D88C00F3939AD02363BA43CEC3AA9939A"},
+	{"ECDSA brainpoolP256r1", "BGrV1U4TCSskAwMCCAEBBwIDBKXS33DKigK07l8nLa8v1h2ALeRHBnCUr3CCmA29zzgeJQTlQSBCDk7s0YgZSTvqIFoeADq+X8h1k+tIFp32//Y=",
+		"C69EE93A09C4B438CBB49FA33AD14495238F814A"},
+	{"ECDH brainpoolP256r1", "BGrV1VISCSskAwMCCAEBBwIDBFjq/KwalmnEA+VGwChz1NQBhxGdmPpHmBuLFRHlmvyOQ/x4H7X7UkLjYLtU47nRCvDIftqE7yZGKzsq22YvhgIDAQgH",
+		"7F81D2833FEB0F0064D7583014F139A2A9C8F31F"},
+	{"ECDSA brainpoolP384r1", "BGrV1aETCSskAwMCCAEBCwMDBDpBMiVsl+L7OXuQSEvVoAGtr+tdFtL50UsJSv8OZ/Q2ONqb126s083iBY087qFsNRcKMgnu/sFvVPjdJRWmsgA9vzfx8H0j8HGK5D7NN3hI8VLY3HZF0Cosrgh/bVz5bQ==",
+		"112F6ABF8353061D044EB92289FA29FC258B582A"},
+	{"ECDSA brainpoolP512r1", "BGrV1aETCSskAwMCCAEBDQQDBDi06OwRfnpZx4fBTNvm6DvcPe6RSkkjikazb16zucpAu6qUQJ00Vv236HIL3eU/U9E8xyixHNZ6LL1NhROnz8QKfAwl8sbfLOTbEJiGT5TDJ6lDDpCg5jHSoc6K1GlkSxY3q8E9R8y218RtSqcpzEu5ge7M5EPrfw5QteATWmpi",
+		"E2B239E5482E0B2C5A6AE8DA96667E38DF4FD001"},
+	{"ECDSA secp256k1", "BGrV1U4TBSuBBAAKAgMElVJ/RgRDypkC66QAz5tWGbMjJ1rfZn3XN3ZuEvUYcYxO2jph96ZXBY7CZ7+CYR9u2Ighujw8Tymm2Xza3KCLbA==",
+		"F4320A63878AEA3DD06B2DE5F0A5AEFE4CA6D5E6"},
+}
+
+func TestKeygrip(t *testing.T) {
+	for _, tc := range keygripTests {
+		contents, err := base64.StdEncoding.DecodeString(tc.packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k, err := parsePubKey(contents)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		grip, err := k.Keygrip()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if grip != tc.keygrip {
+			t.Errorf("%s: got keygrip %s, want %s", tc.name, grip, tc.keygrip)
+		}
+	}
+}
+
+func TestUnsupportedCurve(t *testing.T) {
+	// An ECDSA key on a curve with the made up OID 2B FF
+	contents := []byte{4, 0, 0, 0, 0, 19, 2, 0x2B, 0xFF, 0, 8, 4}
+	if _, err := parsePubKey(contents); err == nil {
+		t.Error("parsed a key on an unknown curve")
+	}
+}
@@ -1,42 +1,47 @@
 package main
 
 import (
-	"crypto/rsa"
-	"crypto/sha1"
-	"fmt"
+	"io"
 	"os"
 	"strings"
-	"sync"
+	"time"
 
-	"github.com/proglottis/gpgme"
-	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/packet"
 )
 
-// GPGME gets very sad when run from lots of goroutines at the same time
-// this lock ensures that all operations are serialized.
-var gpgmeMutex sync.Mutex
-
-func findKey(keypath string) uint64 {
-	r, _ := os.Open(keypath)
+// readRecipients reads the IDs of all keys a message is encrypted to
+func readRecipients(r io.Reader) ([]uint64, error) {
+	var keyIDs []uint64
 	packets := packet.NewReader(r)
 	for {
 		p, err := packets.Next()
+		if err == io.EOF {
+			return keyIDs, nil
+		}
 		if err != nil {
-			return 0
+			return keyIDs, err
 		}
 		switch p := p.(type) {
 		case *packet.EncryptedKey:
-			return p.KeyId
+			keyIDs = append(keyIDs, p.KeyId)
+		case *packet.SymmetricallyEncrypted:
+			// The encrypted keys always come before the encrypted data
+			return keyIDs, nil
 		}
 	}
 }
 
+// KeyInfo describes the key a password is encrypted to
 type KeyInfo struct {
 	GPGAgentKeyInfo
 	Algorithm   string
 	Fingerprint string
 	BitLength   uint16
+	// Secret is set when the secret key is there to decrypt with
+	Secret bool
+	// CacheTTL is how long the passphrase is expected to stay cached,
+	// 0 if unknown
+	CacheTTL time.Duration
 }
 
 // GPGAgentKeyInfo is used for parsing the data from GPGAgent
@@ -54,6 +59,9 @@ type GPGAgentKeyInfo struct {
 
 func parseKeyinfo(statusLine string) GPGAgentKeyInfo {
 	parts := strings.Split(statusLine, " ")
+	if len(parts) < 9 {
+		return GPGAgentKeyInfo{}
+	}
 	return GPGAgentKeyInfo{
 		KeyGrip:     parts[0],
 		Type:        parts[1],
@@ -83,6 +91,8 @@ func init() {
 		packet.PubKeyAlgoDSA:            "DSA",
 		packet.PubKeyAlgoECDH:           "ECDH",
 		packet.PubKeyAlgoECDSA:          "ECDSA",
+		pubKeyAlgoElGamalSign:           "ElGamal",
+		pubKeyAlgoEdDSA:                 "EdDSA",
 	}
 }
 
@@ -90,49 +100,52 @@ func algoString(a packet.PublicKeyAlgorithm) string {
 	return algoNames[a]
 }
 
-// KeyInfo gets the KeyInfo for this password
-func (p *Password) KeyInfo() KeyInfo {
-	gpgmeMutex.Lock()
-	defer gpgmeMutex.Unlock()
-	// Find the keyID for the encrypted data
-	encKeyID := findKey(p.Path)
-
-	// Extract key from gpgme
-	c, _ := gpgme.New()
-	allKeys, err := gpgme.NewData()
-	if err := c.Export(0, allKeys); err != nil {
-		fmt.Printf("error reading all keys %s", err)
-	}
-	allKeys.Seek(0, 0)
-	el, err := openpgp.ReadKeyRing(allKeys)
+// KeyIDs lists the IDs of the keys the password is encrypted to
+func (p *Password) KeyIDs() ([]uint64, error) {
+	file, err := os.Open(p.Path)
 	if err != nil {
-		fmt.Println("Failed to open keyring: ", err.Error())
+		return nil, err
 	}
-	encKeys := el.KeysById(encKeyID)
+	defer file.Close()
+	return backend.RecipientsOf(file)
+}
 
-	// Get the keyInfo for the file
-	var ki KeyInfo
-	if len(encKeys) > 0 {
-		theKey := encKeys[0].PublicKey
-		k := theKey.PublicKey
+// KeyIDs lists the keys any password in the store is encrypted to
+func (ps *PasswordStore) KeyIDs() []uint64 {
+	var keyIDs []uint64
+	seen := make(map[uint64]bool)
+	for _, pw := range ps.passwords {
+		ids, err := pw.KeyIDs()
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				keyIDs = append(keyIDs, id)
+			}
+		}
+	}
+	return keyIDs
+}
 
-		switch k := k.(type) {
-		case *rsa.PublicKey:
-			out := sha1.Sum(append([]byte{0}, k.N.Bytes()...))
-			keygrip := fmt.Sprintf("%X", out)
-			c.SetProtocol(gpgme.ProtocolAssuan)
-			c.AssuanSend("keyinfo "+keygrip, nil, nil,
-				func(status string, args string) error {
-					ki.GPGAgentKeyInfo = parseKeyinfo(args)
-					return nil
-				})
-			ki.Fingerprint = theKey.KeyIdShortString()
-			ki.Algorithm = algoString(theKey.PubKeyAlgo)
-			bl, _ := theKey.BitLength()
-			ki.BitLength = bl
-		default:
-			fmt.Println("Unknown crypto")
+// KeyInfo gets the KeyInfo for the key this password is decrypted with,
+// which is the first recipient there is a secret key for. Without one, it
+// describes the first recipient that is known at all.
+func (p *Password) KeyInfo() KeyInfo {
+	keyIDs, _ := p.KeyIDs()
+	var known KeyInfo
+	for _, id := range keyIDs {
+		ki, err := backend.KeyInfo(id)
+		if ki.Algorithm == "" {
+			continue
+		}
+		if err == nil && ki.Secret {
+			return ki
+		}
+		if known.Algorithm == "" {
+			known = ki
 		}
 	}
-	return ki
+	return known
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestParseKeyinfo(t *testing.T) {
+	ki := parseKeyinfo(testKeygrip + " D - - 1 P - - -")
+	if ki.KeyGrip != testKeygrip || !ki.Cached || ki.Protection != "P" {
+		t.Errorf("got %+v", ki)
+	}
+	if ki := parseKeyinfo("too short"); ki.KeyGrip != "" {
+		t.Errorf("got %+v from a short line", ki)
+	}
+}
+
+func TestPasswordKeyInfo(t *testing.T) {
+	alice, bob, c := newSharedTestCrypto(t)
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+
+	dir := t.TempDir()
+	for name, recipients := range map[string][]string{
+		"both":   {"bob@example.com", "alice@example.com"},
+		"bob":    {"bob@example.com"},
+		"nobody": nil,
+	} {
+		var ciphertext []byte
+		if recipients != nil {
+			ciphertext = encryptTest(t, c, "x", recipients...)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".gpg"), ciphertext, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]*openpgp.Entity{"both": alice, "bob": bob, "nobody": nil} {
+		pw := Password{Name: name, Path: filepath.Join(dir, name+".gpg")}
+		ki := pw.KeyInfo()
+		switch {
+		case want == nil && ki.Algorithm != "":
+			t.Errorf("%s: got %+v", name, ki)
+		case want != nil && ki.Fingerprint != want.Subkeys[0].PublicKey.KeyIdShortString():
+			t.Errorf("%s: got key %s, want %s", name, ki.Fingerprint, want.Subkeys[0].PublicKey.KeyIdShortString())
+		case want == alice && !ki.Secret, want == bob && ki.Secret:
+			t.Errorf("%s: got secret %v", name, ki.Secret)
+		}
+	}
+}
+
+func TestStoreKeyIDs(t *testing.T) {
+	alice, c := newTestCrypto(t, "alice@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+
+	dir := t.TempDir()
+	store := &PasswordStore{Prefix: dir}
+	for _, name := range []string{"a", "b"} {
+		path := filepath.Join(dir, name+".gpg")
+		if err := ioutil.WriteFile(path, encryptTest(t, c, name, "alice@example.com"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		store.passwords = append(store.passwords, Password{Name: name, Path: path})
+	}
+	store.passwords = append(store.passwords, Password{Name: "gone", Path: filepath.Join(dir, "gone.gpg")})
+
+	keyIDs := store.KeyIDs()
+	if want := alice.Subkeys[0].PublicKey.KeyId; len(keyIDs) != 1 || keyIDs[0] != want {
+		t.Errorf("got %X, want %X", keyIDs, want)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rjeczalik/notify"
+)
+
+// keyringCache keeps the parsed public keyring around, so that it doesn't
+// have to be exported and parsed again every time a password is selected.
+// Until the keyring is watched for changes, it is loaded on every lookup.
+type keyringCache struct {
+	mu      sync.Mutex
+	load    func() ([]*pubKey, error)
+	watched bool
+	valid   bool
+	keys    []*pubKey
+	byID    map[uint64][]*pubKey
+}
+
+func newKeyringCache(load func() ([]*pubKey, error)) *keyringCache {
+	return &keyringCache{load: load}
+}
+
+// refresh loads the keyring if it isn't loaded or has changed, kc.mu must be held
+func (kc *keyringCache) refresh() error {
+	if kc.valid && kc.watched {
+		return nil
+	}
+	keys, err := kc.load()
+	if err != nil {
+		return err
+	}
+	kc.keys = keys
+	kc.byID = make(map[uint64][]*pubKey)
+	for _, k := range keys {
+		kc.byID[k.KeyID] = append(kc.byID[k.KeyID], k)
+	}
+	kc.valid = true
+	return nil
+}
+
+// Keys returns all keys in the keyring
+func (kc *keyringCache) Keys() ([]*pubKey, error) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if err := kc.refresh(); err != nil {
+		return nil, err
+	}
+	return kc.keys, nil
+}
+
+// KeysById returns the keys and subkeys with the given key ID
+func (kc *keyringCache) KeysById(id uint64) ([]*pubKey, error) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if err := kc.refresh(); err != nil {
+		return nil, err
+	}
+	return kc.byID[id], nil
+}
+
+// Invalidate makes the next lookup load the keyring again
+func (kc *keyringCache) Invalidate() {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	kc.valid = false
+}
+
+// watch invalidates the cache whenever the keyring in the GnuPG home
+// directory changes
+func (kc *keyringCache) watch(dir string) error {
+	c := make(chan notify.EventInfo, 1)
+	if err := notify.Watch(dir+"/...", c, notify.All); err != nil {
+		return err
+	}
+	kc.mu.Lock()
+	kc.watched = true
+	kc.valid = false
+	kc.mu.Unlock()
+
+	go func() {
+		for ei := range c {
+			// pubring.gpg, pubring.kbx or public-keys.d/pubring.db,
+			// depending on the GnuPG version
+			if strings.HasPrefix(filepath.Base(ei.Path()), "pubring") {
+				kc.Invalidate()
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyringCache(t *testing.T) {
+	loads := 0
+	kc := newKeyringCache(func() ([]*pubKey, error) {
+		loads++
+		return []*pubKey{{KeyID: 1}}, nil
+	})
+
+	// Without a watch, nothing tells the cache about changes
+	kc.KeysById(1)
+	kc.KeysById(1)
+	if loads != 2 {
+		t.Errorf("unwatched keyring loaded %d times, want 2", loads)
+	}
+
+	dir := t.TempDir()
+	if err := kc.watch(dir); err != nil {
+		t.Fatal(err)
+	}
+	loads = 0
+	keys, err := kc.KeysById(1)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("got %v, %v", keys, err)
+	}
+	kc.Keys()
+	if loads != 1 {
+		t.Errorf("watched keyring loaded %d times, want 1", loads)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "pubring.kbx"), nil, 0600)
+	for deadline := time.Now().Add(5 * time.Second); loads == 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("keyring wasn't loaded again after it changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+		kc.Keys()
+	}
+}
+
+func TestKeyringCacheWatchMissingDir(t *testing.T) {
+	kc := newKeyringCache(func() ([]*pubKey, error) { return nil, nil })
+	if err := kc.watch(filepath.Join(os.TempDir(), "gopass-no-such-dir")); err == nil {
+		t.Error("watched a directory that doesn't exist")
+	}
+	if kc.watched {
+		t.Error("keyring is cached without a watch")
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Action is something that can be done with the keyboard, and the keys it
+// is bound to unless configured otherwise
+type Action struct {
+	Name        string
+	Description string
+	Keys        []string
+}
+
+// actions are all actions that can be bound to keys, in the order they're
+// listed in the help
+var actions = []Action{
+	{"copy-password", "Copy the password", []string{"Ctrl+Return"}},
+	{"copy-user", "Copy the user name", []string{"Alt+U"}},
+	{"copy-otp", "Copy the one-time password", []string{"Alt+O"}},
+	{"toggle-metadata", "Show or hide the metadata", []string{"Ctrl+R"}},
+	{"reveal", "Reveal or mask all secret fields", []string{"Ctrl+Shift+R"}},
+	{"edit", "Edit the password", []string{"Ctrl+E"}},
+	{"favourite", "Make the password a favourite, or not", []string{"Ctrl+D"}},
+	{"next", "Select the next entry", []string{"Ctrl+J", "Down"}},
+	{"prev", "Select the previous entry", []string{"Ctrl+K", "Up"}},
+	{"page-down", "Go a page down", []string{"PgDown"}},
+	{"page-up", "Go a page up", []string{"PgUp"}},
+	{"enter-folder", "Show only the selected folder", []string{"Ctrl+Right"}},
+	{"up-folder", "Go to the folder above", []string{"Ctrl+Left"}},
+	{"search", "Select the search box", []string{"Ctrl+L"}},
+	{"audit", "Audit all passwords", []string{"Ctrl+Shift+A"}},
+	{"lock", "Forget cached passphrases", []string{"Ctrl+Shift+L"}},
+	{"help", "Show or hide the key bindings", []string{"F1"}},
+	{"quit", "Close what's open, or quit", []string{"Esc"}},
+}
+
+// keysFile is where key bindings are configured, set with GOPASS_KEYS. Each
+// line binds an action to keys, like "copy-user: Alt+U Ctrl+B", or unbinds
+// it with "copy-user: none".
+func keysFile() string {
+	if p := os.Getenv("GOPASS_KEYS"); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir(), ".config")
+	}
+	return filepath.Join(dir, "gopass", "keys")
+}
+
+// loadBindings reads the key bindings from path, on top of the defaults.
+// Lines that can't be used are skipped, and the first problem is returned.
+func loadBindings(path string) (map[string][]string, error) {
+	bound := make(map[string][]string)
+	for _, a := range actions {
+		bound[a.Name] = a.Keys
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return bound, nil
+	}
+	if err != nil {
+		return bound, err
+	}
+	defer f.Close()
+
+	var problem error
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		_, known := bound[name]
+		switch {
+		case len(parts) != 2:
+			err = fmt.Errorf("%s:%d: Expected action: keys", path, n)
+		case !known:
+			err = fmt.Errorf("%s:%d: Unknown action %s", path, n, name)
+		default:
+			keys := strings.Fields(parts[1])
+			if len(keys) == 1 && keys[0] == "none" {
+				keys = nil
+			}
+			bound[name] = keys
+			continue
+		}
+		if problem == nil {
+			problem = err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return bound, err
+	}
+	return bound, problem
+}
+
+// Binding is a key sequence, and the action it's bound to
+type Binding struct {
+	Action   string
+	Sequence string
+}
+
+// Keys is the model for the key bindings, which QML makes shortcuts of
+type Keys struct {
+	Len int
+	// Help lists the bindings for the help overlay
+	Help     string
+	bindings []Binding
+}
+
+// Get gets the binding at a specific index
+func (k *Keys) Get(index int) Binding {
+	if index < 0 || index >= len(k.bindings) {
+		return Binding{}
+	}
+	return k.bindings[index]
+}
+
+// keyFor is the first key sequence bound to an action, "" when it isn't
+// bound to any
+func (k *Keys) keyFor(action string) string {
+	for _, b := range k.bindings {
+		if b.Action == action {
+			return b.Sequence
+		}
+	}
+	return ""
+}
+
+// load sets up the key bindings configured in path. A key bound to more
+// than one action is only used for the first of them.
+func (k *Keys) load(path string) error {
+	bound, err := loadBindings(path)
+	k.bindings = nil
+	// Enter in the search box always copies, since it's what the search box does
+	help := []string{fmt.Sprintf("%-24s %s", "Enter", "Copy the password")}
+	usedBy := make(map[string]string)
+	for _, a := range actions {
+		var keys []string
+		for _, key := range bound[a.Name] {
+			if other, ok := usedBy[strings.ToLower(key)]; ok {
+				if err == nil {
+					err = fmt.Errorf("%s is bound to both %s and %s", key, other, a.Name)
+				}
+				continue
+			}
+			usedBy[strings.ToLower(key)] = a.Name
+			keys = append(keys, key)
+			k.bindings = append(k.bindings, Binding{a.Name, key})
+		}
+		if len(keys) > 0 {
+			help = append(help, fmt.Sprintf("%-24s %s", strings.Join(keys, ", "), a.Description))
+		}
+	}
+	k.Len = len(k.bindings)
+	k.Help = strings.Join(help, "\n")
+	return err
+}
+
+var shortcuts Keys
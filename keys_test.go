@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetadataHint(t *testing.T) {
+	defer func(k Keys) { shortcuts = k }(shortcuts)
+	for bindings, want := range map[string]string{
+		"":                              "Press Ctrl+R to show metadata",
+		"toggle-metadata: Alt+M Ctrl+M": "Press Alt+M to show metadata",
+		"toggle-metadata: none":         "Click the eye to show metadata",
+		// Ctrl+R goes to the first action it's bound to
+		"copy-password: Ctrl+R": "Click the eye to show metadata",
+	} {
+		path := filepath.Join(t.TempDir(), "keys")
+		if err := ioutil.WriteFile(path, []byte(bindings), 0600); err != nil {
+			t.Fatal(err)
+		}
+		shortcuts = Keys{}
+		shortcuts.load(path)
+		if got := metadataHint(); got != want {
+			t.Errorf("with %q the hint is %q, want %q", bindings, got, want)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package main
 
-//go:generate go run vendor/github.com/limetext/qml-go/cmd/genqrc/main.go assets
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
@@ -20,48 +20,88 @@ type UI struct {
 	countdownDone chan bool
 
 	ShowMetadata bool
+	// Decrypting is set while waiting for a password to be decrypted
+	Decrypting bool
+	// AskingPassphrase is set while the passphrase dialog is shown,
+	// when using loopback pinentry
+	AskingPassphrase bool
+	PassphraseHint   string
+	passphrase       chan []byte
+
+	// AuditReport lists the problems found by the last audit
+	AuditReport string
+	ShowAudit   bool
+
+	idle *idleLock
+	// background is set when gopass keeps running with the window hidden
+	background bool
 
 	Password struct {
-		Name     string
-		Metadata string
-		Info     string
-		Cached   bool
+		Name       string
+		Metadata   string
+		Info       string
+		Cached     bool
+		Recipients string
 	}
 }
 
-// Passwords is the model for the password list
+// Passwords is the model for the password list, which shows the store as
+// a tree of folders, or the passwords matching the query
 type Passwords struct {
 	Selected int
 	Len      int
+	// Folder is the folder shown, "" for the whole store
+	Folder   string
 	store    *PasswordStore
-	hits     []Password
+	rows     []Entry
+	expanded map[string]bool
 }
 
-// Quit the application
+// Quit the application, or hide the window when running in the background
 func (ui *UI) Quit() {
+	if ui.background && window != nil {
+		ui.Clearmetadata()
+		window.Hide()
+		return
+	}
+	ui.Exit()
+}
+
+// Exit the application, even when running in the background
+func (ui *UI) Exit() {
+	removeInstanceSocket()
+	custom.close()
 	os.Exit(0)
 }
 
-// Clearmetadata clears the displayed metadata
+// Clearmetadata hides the revealed metadata
 func (ui *UI) Clearmetadata() {
-	ui.setMetadata("")
+	ui.hideMetadata("")
+}
+
+// Lock makes gpg-agent forget the cached passphrases of the store's keys
+func (ui *UI) Lock() {
+	ui.lock("Locked")
 }
 
-// ToggleShowMetadata toggles between showing and not showing metadata
+// ToggleShowMetadata reveals the metadata of the selected password, or
+// hides it again
 func (ui *UI) ToggleShowMetadata() {
-	ui.ShowMetadata = !ui.ShowMetadata
-	passwords.Update("")
-	qml.Changed(ui, &ui.ShowMetadata)
+	ui.idle.activity()
+	if ui.ShowMetadata {
+		ui.hideMetadata("")
+		return
+	}
+	ui.Reveal()
 }
 
-// Get gets the password at a specific index
-func (p *Passwords) Get(index int) Password {
-	if index > len(p.hits) {
-		fmt.Println("Bad password fetch", index, len(p.hits), p.Len)
-		return Password{}
+// Get gets the row at a specific index
+func (p *Passwords) Get(index int) Entry {
+	if index >= len(p.rows) {
+		fmt.Println("Bad password fetch", index, len(p.rows), p.Len)
+		return Entry{}
 	}
-	pw := p.hits[index]
-	return pw
+	return p.rows[index]
 }
 
 // ClearClipboard clears the clipboard
@@ -95,24 +135,69 @@ func (ui *UI) ClearClipboard() {
 	}
 }
 
-// CopyToClipboard copies the selected password to the system clipboard
+// CopyToClipboard copies the selected password to the system clipboard,
+// or expands the selected folder
 func (p *Passwords) CopyToClipboard(selected int) {
-	if selected >= len(p.hits) {
+	ui.idle.activity()
+	if selected < len(p.rows) && p.rows[selected].IsFolder {
+		p.Toggle(selected)
+		return
+	}
+	if selected >= len(p.rows) {
 		ui.setStatus("No password selected")
 		return
 	}
-	pw := (p.hits)[selected]
-	pass := pw.Password()
-	if err := clipboard.WriteAll(pass); err != nil {
+	pw := p.rows[selected].pw
+	ui.decrypt(pw, pw.Password, func(pass *Secret) {
+		p.copy(pass, "Copied to clipboard")
+	})
+}
+
+// CopyUser copies the user name of the selected password
+func (p *Passwords) CopyUser(selected int) {
+	p.copyFromMetadata(selected, "User name", userName)
+}
+
+// CopyOTP copies the current one-time password of the selected password
+func (p *Passwords) CopyOTP(selected int) {
+	p.copyFromMetadata(selected, "One-time password", otpCode)
+}
+
+// copyFromMetadata copies what pick finds in the metadata of the selected
+// password
+func (p *Passwords) copyFromMetadata(selected int, what string, pick func(*Secret) (*Secret, error)) {
+	ui.idle.activity()
+	if selected < 0 || selected >= len(p.rows) || p.rows[selected].IsFolder {
+		ui.setStatus("No password selected")
+		return
+	}
+	pw := p.rows[selected].pw
+	ui.decrypt(pw, func() (*Secret, error) {
+		metadata, err := pw.Metadata()
+		if err != nil {
+			return nil, err
+		}
+		return pick(metadata)
+	}, func(s *Secret) {
+		p.copy(s, what+" copied to clipboard")
+	})
+}
+
+// copy puts a secret in the clipboard until it's cleared, and wipes it
+func (p *Passwords) copy(s *Secret, status string) {
+	// The clipboard only takes strings, so this copy can't be wiped
+	err := clipboard.WriteAll(s.String())
+	s.Wipe()
+	if err != nil {
 		panic(err)
 	}
-	ui.setStatus("Copied to clipboard")
 	go ui.ClearClipboard()
-	p.Update("") // Trigger a manual update, since the key is probably unlocked now
+	p.Update(status) // Trigger a manual update, since the key is probably unlocked now
 }
 
 // Select the password with the specified index
 func (p *Passwords) Select(selected int) {
+	ui.idle.activity()
 	p.Selected = selected
 	// Trigger an update in a goroutine to keep QML from warning about a binding loop
 	go func() { p.Update("") }()
@@ -120,6 +205,7 @@ func (p *Passwords) Select(selected int) {
 
 // Query updates the hitlist with the given query
 func (ui *UI) Query(q string) {
+	ui.idle.activity()
 	ui.query = q
 	passwords.Update("queried")
 }
@@ -133,76 +219,189 @@ func (ui *UI) setCountdown(c float64) {
 	ui.Countdown = c
 	qml.Changed(ui, &ui.Countdown)
 }
-func (ui *UI) setMetadata(s string) {
-	ui.Password.Metadata = s
-	qml.Changed(ui, &ui.Password.Metadata)
-}
 
 // Update is called whenever the store is updated, so the UI needs refreshing
 func (p *Passwords) Update(status string) {
-	p.hits = p.store.Query(ui.query)
-	p.Len = len(p.hits)
-
-	var pw Password
+	if p.Folder != "" && p.store.Tree().Find(p.Folder) == nil {
+		// The folder was removed
+		p.Folder = ""
+		qml.Changed(p, &p.Folder)
+	}
+	if ui.query == "" {
+		p.rows = nil
+		if p.Folder == "" {
+			p.rows = p.store.favouriteRows()
+		}
+		p.rows = append(p.rows, treeRows(p.store.Tree().Find(p.Folder), 0, p.expanded)...)
+	} else if hits, err := p.store.Query(ui.query); err != nil {
+		// Keep showing the last results while the query is being typed
+		status = err.Error()
+	} else {
+		p.store.pinFavourites(hits)
+		p.rows = searchRows(hits, p.Folder)
+	}
+	for i, row := range p.rows {
+		p.rows[i].Favourite = !row.IsFolder && p.store.IsFavourite(row.Path)
+	}
+	p.Len = len(p.rows)
+	facets.update(p.store.Tags())
 
-	ui.Password.Info = "Test"
-	if p.Selected < p.Len {
-		pw = (p.hits)[p.Selected]
+	pw, ok := p.selected()
+	if p.Selected < p.Len && !ok {
+		ui.Password.Name = p.rows[p.Selected].Path
+		ui.Password.Info = "Folder"
+		ui.Password.Cached = false
+		ui.Password.Recipients = ""
+	}
+	if ok {
 		ki := pw.KeyInfo()
 		if ki.Algorithm != "" {
 			ui.Password.Info = fmt.Sprintf("Encrypted with %d bit %s key %s",
 				ki.BitLength, ki.Algorithm, ki.Fingerprint)
+			if ki.Cached && ki.CacheTTL > 0 {
+				ui.Password.Info += fmt.Sprintf(", unlocked for %s", ki.CacheTTL/time.Second*time.Second)
+			}
 			ui.Password.Cached = ki.Cached
 		} else {
 			ui.Password.Info = "Not encrypted"
 			ui.Password.Cached = false
 		}
 		ui.Password.Name = pw.Name
+		ui.Password.Recipients = ""
+		if recipients, err := p.store.Recipients(pw); err == nil {
+			var lines []string
+			for _, r := range recipients {
+				lines = append(lines, r.String())
+			}
+			ui.Password.Recipients = strings.Join(lines, "\n")
+		}
 	}
 
-	if ui.ShowMetadata {
-		ui.Password.Metadata = pw.Metadata()
-	} else {
-		ui.Password.Metadata = "Press enter to decrypt"
-		ui.Password.Metadata = pw.Raw()
+	// Decrypting for a password that's no longer selected is pointless
+	decrypter.cancelOthers(pw.Name)
+	// Revealed metadata only stays while its password is selected
+	if ui.ShowMetadata && revealed.showing() != pw.Name {
+		ui.hideMetadata("")
+	}
+	ui.Password.Metadata = ""
+	if ok {
+		ui.Password.Metadata = metadataHint()
 	}
 	qml.Changed(p, &p.Len)
 	qml.Changed(&ui, &ui.Password)
 	qml.Changed(&ui, &ui.Password.Metadata)
 	qml.Changed(&ui, &ui.Password.Name)
+	qml.Changed(&ui, &ui.Password.Recipients)
 	ui.setStatus(status)
 }
 
+// metadataHint tells how to show the metadata, with the key it's bound to
+func metadataHint() string {
+	if key := shortcuts.keyFor("toggle-metadata"); key != "" {
+		return "Press " + key + " to show metadata"
+	}
+	return "Click the eye to show metadata"
+}
+
 var ui UI
 var passwords Passwords
 var ps *PasswordStore
+var window *qml.Window
 
 func main() {
+	if err := disableCoreDumps(); err != nil {
+		fmt.Fprintln(os.Stderr, "Couldn't disable core dumps:", err)
+	}
+	if len(os.Args) == 1 && activateRunning() {
+		return
+	}
+	var err error
+	if backend, err = newCrypto(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
 	ps = NewPasswordStore()
-	passwords.store = ps
-	ps.Subscribe(passwords.Update)
-	passwords.Update("Started")
-	if err := qml.Run(run); err != nil {
+	if len(os.Args) > 1 {
+		err = runCommand(os.Args[1:])
+	} else {
+		err = runUI()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// runUI starts the UI, and keeps running until it is quit
+func runUI() error {
+	if err := listenForActivation(); err != nil {
+		return err
+	}
+	defer removeInstanceSocket()
+	passwords.store = ps
+	passwords.expanded = make(map[string]bool)
+	ui.countdownDone = make(chan bool)
+	decrypter = newDecryptWorker(ui.setDecrypting)
+	ui.passphrase = make(chan []byte)
+	if asker, ok := backend.(passphraseAsker); ok {
+		asker.AskPassphrase(func(hint string) ([]byte, error) {
+			return ui.askPassphrase(decrypter.currentContext(), hint)
+		})
+	}
+	if err := setupTheme(); err != nil {
+		fmt.Fprintln(os.Stderr, "Couldn't load theme:", err)
+	}
+	if err := shortcuts.load(keysFile()); err != nil {
+		fmt.Fprintln(os.Stderr, "Problem with key bindings:", err)
+	}
+	ui.idle = newIdleLock(idleTimeout(), func() { ui.lock("Locked after being idle") })
+	ui.idle.activity()
+	watchScreenLock(func() { ui.lock("Locked since the screen was locked") })
+	ps.Subscribe(passwords.Update)
+	if indexEnabled() {
+		ps.startIndex()
+	}
+	passwords.Update("Started")
+	return qml.Run(run)
+}
+
 func run() error {
 	qml.SetApplicationName("GoPass")
-	engine := qml.NewEngine()
-	engine.Context().SetVar("passwords", &passwords)
-	engine.Context().SetVar("ui", &ui)
-	_, err := engine.LoadFile("qrc:/assets/RoundButton.qml")
-	if err != nil {
-		return err
+	prefix := "qrc:/assets/"
+	if dir := assetsDir(); dir != "" {
+		custom = &customAssets{dir: dir}
+		defer custom.close()
+		var err error
+		if prefix, err = custom.load(); err != nil {
+			return err
+		}
 	}
-	controls, err := engine.LoadFile("qrc:/assets/main.qml")
+	engine, w, err := loadWindow(prefix)
 	if err != nil {
 		return err
 	}
-	window := controls.CreateWindow(nil)
-	window.Show()
-	window.Wait()
-	return nil
+	window = w
+	if ui.background {
+		// The tray icon needs Qt.labs.platform, so do without when it's missing.
+		// It has an engine of its own, so it stays when the window is reloaded.
+		if tray, err := newEngine().LoadFile(prefix + "Tray.qml"); err == nil {
+			tray.Create(nil)
+		} else {
+			fmt.Fprintln(os.Stderr, "No tray icon:", err)
+		}
+	} else {
+		window.Show()
+	}
+	if custom != nil {
+		custom.engine = engine
+		go custom.watch()
+	}
+	for {
+		w := window
+		w.Wait()
+		// The window is replaced when custom assets are reloaded
+		if w == window {
+			return nil
+		}
+	}
 }
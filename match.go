@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+)
+
+// MatchSource tells where a query term was found
+type MatchSource int
+
+const (
+	// MatchName is the name of the password itself
+	MatchName MatchSource = iota
+	// MatchFolder is one of the folders the password is in
+	MatchFolder
+	// MatchMetadata is indexed metadata of the password
+	MatchMetadata
+)
+
+func (s MatchSource) String() string {
+	switch s {
+	case MatchFolder:
+		return "folder"
+	case MatchMetadata:
+		return "metadata"
+	}
+	return "name"
+}
+
+// Span is where a query term matched. Start and End are byte offsets in
+// the password's name, unless the term was found in metadata.
+type Span struct {
+	Start, End int
+	Term       string
+	Source     MatchSource
+}
+
+// Hit is a password matching a query, and why it matched
+type Hit struct {
+	Password
+	Spans []Span
+}
+
+// Reason tells where the terms of the query were found
+func (h Hit) Reason() string {
+	var sources []string
+	seen := make(map[MatchSource]bool)
+	for _, s := range h.Spans {
+		if !seen[s.Source] {
+			seen[s.Source] = true
+			sources = append(sources, s.Source.String())
+		}
+	}
+	if len(sources) == 0 {
+		return ""
+	}
+	return "in " + strings.Join(sources, " and ")
+}
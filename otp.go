@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otpParams is how the one-time passwords of an account are generated, as
+// given in an otpauth:// URI
+type otpParams struct {
+	secret []byte
+	digits int
+	period int64
+	hash   func() hash.Hash
+}
+
+// parseOTP reads an otpauth://totp/ URI, or a bare base32 secret with the
+// usual defaults. The secret should be wiped after use.
+func parseOTP(value string) (*otpParams, error) {
+	params := &otpParams{digits: 6, period: 30, hash: sha1.New}
+	secret := value
+	if strings.HasPrefix(value, "otpauth://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		if u.Host != "totp" {
+			return nil, fmt.Errorf("Unsupported one-time password type %s", u.Host)
+		}
+		q := u.Query()
+		secret = q.Get("secret")
+		if d := q.Get("digits"); d != "" {
+			if params.digits, err = strconv.Atoi(d); err != nil || params.digits < 6 || params.digits > 10 {
+				return nil, errors.New("Bad number of digits in one-time password")
+			}
+		}
+		if p := q.Get("period"); p != "" {
+			if params.period, err = strconv.ParseInt(p, 10, 64); err != nil || params.period <= 0 {
+				return nil, errors.New("Bad period in one-time password")
+			}
+		}
+		switch strings.ToUpper(q.Get("algorithm")) {
+		case "", "SHA1":
+		case "SHA256":
+			params.hash = sha256.New
+		case "SHA512":
+			params.hash = sha512.New
+		default:
+			return nil, fmt.Errorf("Unsupported one-time password algorithm %s", q.Get("algorithm"))
+		}
+	}
+	secret = strings.ToUpper(strings.Replace(strings.TrimRight(secret, "="), " ", "", -1))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("Bad one-time password secret")
+	}
+	params.secret = key
+	return params, nil
+}
+
+// code generates the one-time password for the given time, as in RFC 6238
+func (p *otpParams) code(now time.Time) *Secret {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(now.Unix()/p.period))
+	mac := hmac.New(p.hash, p.secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	defer wipe(sum)
+	offset := sum[len(sum)-1] & 0xf
+	value := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < p.digits; i++ {
+		mod *= 10
+	}
+	code := newSecret(p.digits)
+	fmt.Fprintf(code, "%0*d", p.digits, value%mod)
+	return code
+}
+
+// otpCode finds the one-time password setup in metadata, and generates the
+// current code
+func otpCode(metadata *Secret) (*Secret, error) {
+	defer metadata.Wipe()
+	for _, f := range parseFields(metadata.Bytes()) {
+		key := strings.ToLower(string(f.key))
+		if !bytes.HasPrefix(f.value, []byte("otpauth://")) && key != "totp" && key != "otp" {
+			continue
+		}
+		params, err := parseOTP(string(f.value))
+		if err != nil {
+			return nil, err
+		}
+		defer wipe(params.secret)
+		return params.code(time.Now()), nil
+	}
+	return nil, errors.New("No one-time password for this password")
+}
+
+// userName finds the user name in metadata
+func userName(metadata *Secret) (*Secret, error) {
+	defer metadata.Wipe()
+	for _, f := range parseFields(metadata.Bytes()) {
+		if indexedKeys[strings.ToLower(string(f.key))] == "user" && !f.secret {
+			return secretFrom(f.value), nil
+		}
+	}
+	return nil, errors.New("No user name for this password")
+}
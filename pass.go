@@ -1,12 +1,8 @@
 package main
 
 import (
-	"bufio"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/user"
@@ -14,7 +10,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/proglottis/gpgme"
 	"github.com/rjeczalik/notify"
 )
 
@@ -23,6 +18,11 @@ type PasswordStore struct {
 	passwords   []Password
 	Prefix      string
 	subscribers []Subscriber
+	// index has the metadata used by queries, nil when not indexed
+	index metadataIndex
+	// reindex asks for the index to be refreshed
+	reindex    chan bool
+	favourites map[string]bool
 }
 
 // Subscriber is a callback for changes in the PasswordStore
@@ -30,40 +30,39 @@ type Subscriber func(status string)
 
 // A Password entry in Passwords
 type Password struct {
+	// Name is the path relative to the store without .gpg, which is what
+	// identifies the password and what queries are matched against
 	Name string
 	Path string
 }
 
-func (p *Password) decrypt() (io.Reader, error) {
-	gpgmeMutex.Lock()
-	defer gpgmeMutex.Unlock()
-	file, _ := os.Open(p.Path)
-	defer file.Close()
-	return gpgme.Decrypt(file)
-}
-
-// Raw returns the password in encrypted form
-func (p *Password) Raw() string {
-	file, _ := os.Open(p.Path)
+func (p *Password) decrypt() (*Secret, error) {
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
 	defer file.Close()
-	data, _ := ioutil.ReadAll(file)
-	return base64.StdEncoding.EncodeToString(data)
+	return backend.Decrypt(file)
 }
 
-// Metadata of the password
-func (p *Password) Metadata() string {
-	out, _ := p.decrypt()
-	nr := bufio.NewReader(out)
-	nr.ReadString('\n')
-	metadata, _ := nr.ReadString('\003')
-	return metadata
+// Metadata of the password, everything after the first line
+func (p *Password) Metadata() (*Secret, error) {
+	decrypted, err := p.decrypt()
+	if err != nil {
+		return nil, err
+	}
+	defer decrypted.Wipe()
+	return decrypted.Rest(), nil
 }
 
-func (p *Password) Password() string {
-	decrypted, _ := p.decrypt()
-	nr := bufio.NewReader(decrypted)
-	password, _ := nr.ReadString('\n')
-	return password
+// Password returns the first line of the decrypted password file
+func (p *Password) Password() (*Secret, error) {
+	decrypted, err := p.decrypt()
+	if err != nil {
+		return nil, err
+	}
+	defer decrypted.Wipe()
+	return decrypted.Line(), nil
 }
 
 // NewPasswordStore creates a new password store
@@ -79,15 +78,19 @@ func NewPasswordStore() *PasswordStore {
 	return ps
 }
 
-// Query the PasswordStore
-func (ps *PasswordStore) Query(q string) []Password {
-	var hits []Password
+// Query the PasswordStore, returning the passwords that match and why
+func (ps *PasswordStore) Query(q string) ([]Hit, error) {
+	terms, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	var hits []Hit
 	for _, p := range ps.passwords {
-		if match(q, p.Name) {
-			hits = append(hits, p)
+		if spans, ok := ps.matchTerms(terms, p); ok {
+			hits = append(hits, Hit{Password: p, Spans: spans})
 		}
 	}
-	return hits
+	return hits, nil
 }
 
 // Subscribe starts calling cb when anything in the PasswordStore changes
@@ -101,41 +104,23 @@ func (ps *PasswordStore) publishUpdate(status string) {
 	}
 }
 
-func match(query, candidate string) bool {
-	lowerQuery := strings.ToLower(query)
-	queryParts := strings.Split(lowerQuery, " ")
-
-	lowerCandidate := strings.ToLower(candidate)
-
-	for _, p := range queryParts {
-		if !strings.Contains(
-			strings.ToLower(lowerCandidate),
-			strings.ToLower(p),
-		) {
-			return false
-		}
-	}
-	return true
-
-}
-
-func (ps *PasswordStore) indexFile(path string, info os.FileInfo, err error) error {
-	if strings.HasSuffix(path, ".gpg") {
-		name := strings.TrimPrefix(path, ps.Prefix)
-		name = strings.TrimSuffix(name, ".gpg")
-		name = strings.TrimPrefix(name, "/")
-		const MaxLen = 40
-		if len(name) > MaxLen {
-			name = "..." + name[len(name)-MaxLen:]
-		}
-
-		ps.add(Password{Name: name, Path: path})
-	}
-	return nil
-}
-
+// indexAll finds all passwords in the store. The new list replaces the old
+// one all at once, so nobody sees the store half indexed.
 func (ps *PasswordStore) indexAll() {
-	filepath.Walk(ps.Prefix, ps.indexFile)
+	var passwords []Password
+	filepath.Walk(ps.Prefix, func(path string, info os.FileInfo, err error) error {
+		if strings.HasSuffix(path, ".gpg") {
+			name := strings.TrimPrefix(path, ps.Prefix)
+			name = strings.TrimSuffix(name, ".gpg")
+			name = strings.TrimPrefix(name, "/")
+
+			passwords = append(passwords, Password{Name: name, Path: path})
+		}
+		return nil
+	})
+	ps.loadFavourites()
+	ps.passwords = passwords
+	ps.publishUpdate(fmt.Sprintf("Indexed %d entries", len(passwords)))
 }
 
 func (ps *PasswordStore) watch() {
@@ -145,24 +130,24 @@ func (ps *PasswordStore) watch() {
 	}
 
 	go func() {
-		for {
-			<-c
+		for ev := range c {
 			ps.indexAll()
+			if strings.HasSuffix(ev.Path(), ".gpg") {
+				ps.requestReindex()
+			}
 		}
 	}()
 }
 
-func (ps *PasswordStore) add(p Password) {
-	ps.passwords = append(ps.passwords, p)
-	ps.publishUpdate(fmt.Sprintf("Indexed %d entries", len(ps.passwords)))
+func homeDir() string {
+	if usr, err := user.Current(); err == nil {
+		return usr.HomeDir
+	}
+	return ""
 }
 
 func findPasswordStore() (string, error) {
-
-	var homeDir string
-	if usr, err := user.Current(); err == nil {
-		homeDir = usr.HomeDir
-	}
+	homeDir := homeDir()
 
 	pathCandidates := []string{
 		os.Getenv("PASSWORD_STORE_DIR"),
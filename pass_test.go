@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndexAll(t *testing.T) {
+	store := &PasswordStore{Prefix: t.TempDir()}
+	os.MkdirAll(filepath.Join(store.Prefix, "Work", "Mail"), 0700)
+	for _, name := range []string{".gpg-id", "Bank.gpg", "Work/Git.gpg", "Work/Mail/Gmail.gpg", "notes.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(store.Prefix, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var statuses []string
+	var seen [][]Password
+	store.Subscribe(func(status string) {
+		statuses = append(statuses, status)
+		seen = append(seen, store.passwords)
+	})
+
+	store.indexAll()
+	var names []string
+	for _, pw := range store.passwords {
+		names = append(names, pw.Name)
+	}
+	if want := []string{"Bank", "Work/Git", "Work/Mail/Gmail"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("indexed %q, want %q", names, want)
+	}
+	if len(statuses) != 1 || statuses[0] != "Indexed 3 entries" {
+		t.Errorf("published %q, want a single update", statuses)
+	}
+
+	// Indexing again doesn't show subscribers an empty store
+	os.Remove(filepath.Join(store.Prefix, "Bank.gpg"))
+	store.indexAll()
+	if len(seen) != 2 || len(seen[1]) != 2 {
+		t.Errorf("subscribers saw %v", seen)
+	}
+}
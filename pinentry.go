@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"github.com/limetext/qml-go"
+)
+
+// askPassphrase shows the passphrase dialog and waits for the user to
+// enter a passphrase or cancel. It's called from the decryption worker,
+// and gives up as soon as ctx is cancelled, so a dialog for a request
+// that's no longer wanted doesn't show up or stay open.
+func (ui *UI) askPassphrase(ctx context.Context, hint string) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errCancelled
+	default:
+	}
+	ui.PassphraseHint = hint
+	ui.AskingPassphrase = true
+	qml.Changed(ui, &ui.PassphraseHint)
+	qml.Changed(ui, &ui.AskingPassphrase)
+	defer func() {
+		ui.AskingPassphrase = false
+		qml.Changed(ui, &ui.AskingPassphrase)
+	}()
+
+	select {
+	case pass := <-ui.passphrase:
+		if pass == nil {
+			return nil, errCancelled
+		}
+		return pass, nil
+	case <-ctx.Done():
+		return nil, errCancelled
+	}
+}
+
+// SubmitPassphrase answers the passphrase dialog
+func (ui *UI) SubmitPassphrase(pass string) {
+	ui.idle.activity()
+	ui.answerPassphrase([]byte(pass))
+}
+
+// CancelPassphrase closes the passphrase dialog without decrypting
+func (ui *UI) CancelPassphrase() {
+	ui.answerPassphrase(nil)
+}
+
+// answerPassphrase hands the passphrase to askPassphrase, if it's waiting
+func (ui *UI) answerPassphrase(pass []byte) {
+	select {
+	case ui.passphrase <- pass:
+	default:
+		wipe(pass)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// answerWhenAsked keeps answering until askPassphrase takes the answer,
+// since it's dropped when nobody is waiting for it yet
+func answerWhenAsked(ui *UI, pass []byte, asked <-chan bool) {
+	for {
+		select {
+		case <-asked:
+			return
+		case <-time.After(10 * time.Millisecond):
+			ui.answerPassphrase(append([]byte(nil), pass...))
+		}
+	}
+}
+
+func TestAskPassphrase(t *testing.T) {
+	ui := &UI{passphrase: make(chan []byte)}
+	asked := make(chan bool)
+	var pass []byte
+	var err error
+	go func() {
+		pass, err = ui.askPassphrase(context.Background(), "Alice's key")
+		close(asked)
+	}()
+	answerWhenAsked(ui, []byte("hunter2"), asked)
+	if err != nil || string(pass) != "hunter2" {
+		t.Errorf("got %q, %v, want the passphrase", pass, err)
+	}
+	if ui.AskingPassphrase {
+		t.Error("dialog still shown after answering")
+	}
+
+	asked = make(chan bool)
+	go func() {
+		pass, err = ui.askPassphrase(context.Background(), "Alice's key")
+		close(asked)
+	}()
+	answerWhenAsked(ui, nil, asked)
+	if err != errCancelled {
+		t.Errorf("got %q, %v, want it cancelled", pass, err)
+	}
+}
+
+func TestAskPassphraseCancelled(t *testing.T) {
+	ui := &UI{passphrase: make(chan []byte)}
+
+	// A request that's already cancelled doesn't show the dialog at all
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ui.askPassphrase(ctx, "Alice's key"); err != errCancelled {
+		t.Errorf("got %v, want it cancelled", err)
+	}
+	if ui.PassphraseHint != "" {
+		t.Error("dialog shown for a cancelled request")
+	}
+
+	// Cancelling while waiting closes it
+	ctx, cancel = context.WithCancel(context.Background())
+	asked := make(chan error)
+	go func() {
+		_, err := ui.askPassphrase(ctx, "Alice's key")
+		asked <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-asked:
+		if err != errCancelled {
+			t.Errorf("got %v, want it cancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("still waiting for a passphrase after cancelling")
+	}
+	if ui.AskingPassphrase {
+		t.Error("dialog still shown after cancelling")
+	}
+}
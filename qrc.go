@@ -1,58 +0,0 @@
-package main
-
-// This file is automatically generated by github.com/limetext/qml-go/cmd/genqrc
-
-import (
-	"io/ioutil"
-	"os"
-	"path/filepath"
-
-	"github.com/limetext/qml-go"
-)
-
-func init() {
-	var r *qml.Resources
-	var err error
-	if os.Getenv("QRC_REPACK") == "1" {
-		err = qrcRepackResources()
-		if err != nil {
-			panic("cannot repack qrc resources: " + err.Error())
-		}
-		r, err = qml.ParseResources(qrcResourcesRepacked)
-	} else {
-		r, err = qml.ParseResourcesString(qrcResourcesData)
-	}
-	if err != nil {
-		panic("cannot parse bundled resources data: " + err.Error())
-	}
-	qml.LoadResources(r)
-}
-
-func qrcRepackResources() error {
-	subdirs := []string{"assets"}
-	var rp qml.ResourcesPacker
-	for _, subdir := range subdirs {
-		err := filepath.Walk(subdir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
-			data, err := ioutil.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			rp.Add(filepath.ToSlash(path), data)
-			return nil
-		})
-		if err != nil {
-			return err
-		}
-	}
-	qrcResourcesRepacked = rp.Pack().Bytes()
-	return nil
-}
-
-var qrcResourcesRepacked []byte
-var qrcResourcesData = "qres\x00\x00\x00\x01\x00\x00\x87\x92\x00\x00\x00\x14\x00\x00\x86\xda\x00\x00\x02\xfaimport QtQuick 2.3\n\nRectangle {\n    id: container\n    width: 100; height: 25\n    border.color: btnColor\n    color:\"transparent\"\n    radius: 10\n    property string label: \"label\"\n    property var btnColor: \"#999\"\n    signal clicked()\n    MouseArea {\n        anchors.fill: parent\n        onClicked: container.clicked()\n        hoverEnabled: true\n        onEntered: {\n           parent.color=\"#666\" \n        }\n        onExited: {\n           parent.color=\"transparent\" \n        }\n    }\n    Text {\n        anchors.fill: parent\n        anchors.horizontalCenter: parent.horizontalCenter\n        horizontalAlignment: Text.AlignHCenter\n        verticalAlignment: Text.AlignVCenter\n        text:parent.label\n        color:parent.btnColor\n        font.pixelSize: 10\n    }\n}\x00\x00\x16\xf4<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n<!-- Created with Inkscape (http://www.inkscape.org/) -->\n\n<svg\n   xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n   xmlns:cc=\"http://creativecommons.org/ns#\"\n   xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\"\n   xmlns:svg=\"http://www.w3.org/2000/svg\"\n   xmlns=\"http://www.w3.org/2000/svg\"\n   xmlns:xlink=\"http://www.w3.org/1999/xlink\"\n   xmlns:sodipodi=\"http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd\"\n   xmlns:inkscape=\"http://www.inkscape.org/namespaces/inkscape\"\n   width=\"64\"\n   height=\"64\"\n   id=\"svg4265\"\n   version=\"1.1\"\n   inkscape:version=\"0.91 r13725\"\n   viewBox=\"0 0 64 64\"\n   sodipodi:docname=\"logo.svg\">\n  <defs\n     id=\"defs4267\">\n    <linearGradient\n       inkscape:collect=\"always\"\n       id=\"linearGradient4891\">\n      <stop\n         style=\"stop-color:#e6e6e6;stop-opacity:1\"\n         offset=\"0\"\n         id=\"stop4893\" />\n      <stop\n         style=\"stop-color:#575757;stop-opacity:1\"\n         offset=\"1\"\n         id=\"stop4895\" />\n    </linearGradient>\n    <radialGradient\n       inkscape:collect=\"always\"\n       xlink:href=\"#linearGradient4891\"\n       id=\"radialGradient4901\"\n       cx=\"28.997917\"\n       cy=\"10.516196\"\n       fx=\"28.997917\"\n       fy=\"10.516196\"\n       r=\"18.54099\"\n       gradientTransform=\"matrix(0.79739441,-0.66023391,0.48669645,0.58780536,-1.8659729,26.464997)\"\n       gradientUnits=\"userSpaceOnUse\" />\n  </defs>\n  <sodipodi:namedview\n     id=\"base\"\n     pagecolor=\"#ffffff\"\n     bordercolor=\"#666666\"\n     borderopacity=\"1.0\"\n     inkscape:pageopacity=\"0.0\"\n     inkscape:pageshadow=\"2\"\n     inkscape:zoom=\"8\"\n     inkscape:cx=\"-5.6325773\"\n     inkscape:cy=\"32.144434\"\n     inkscape:current-layer=\"layer1\"\n     showgrid=\"true\"\n     inkscape:document-units=\"px\"\n     inkscape:grid-bbox=\"true\"\n     showguides=\"false\"\n     inkscape:guide-bbox=\"true\"\n     inkscape:window-width=\"2560\"\n     inkscape:window-height=\"1376\"\n     inkscape:window-x=\"0\"\n     inkscape:window-y=\"27\"\n     inkscape:window-maximized=\"1\" />\n  <metadata\n     id=\"metadata4270\">\n    <rdf:RDF>\n      <cc:Work\n         rdf:about=\"\">\n        <dc:format>image/svg+xml</dc:format>\n        <dc:type\n           rdf:resource=\"http://purl.org/dc/dcmitype/StillImage\" />\n        <dc:title></dc:title>\n      </cc:Work>\n    </rdf:RDF>\n  </metadata>\n  <g\n     inkscape:groupmode=\"layer\"\n     id=\"layer3\"\n     inkscape:label=\"Hanger\"\n     style=\"display:inline\">\n    <path\n       style=\"display:inline;opacity:1;fill:none;fill-opacity:1;stroke:url(#radialGradient4901);stroke-width:5;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"m 32.5,4.731595 c 10.648,0 16.620529,5.381024 16,12 l -1.5,16 c -1.949438,17.87752 -28.552416,14.821066 -29,0 l -1.5,-16 c -0.620529,-6.618976 5.352,-12 16,-12 z\"\n       id=\"rect4871\"\n       inkscape:connector-curvature=\"0\"\n       sodipodi:nodetypes=\"csccsc\" />\n  </g>\n  <g\n     id=\"layer1\"\n     inkscape:label=\"Layer 1\"\n     inkscape:groupmode=\"layer\"\n     style=\"display:inline\">\n    <rect\n       style=\"fill:#ffaaee;fill-opacity:1;stroke:none;stroke-width:1;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4320\"\n       width=\"13.370747\"\n       height=\"11.827968\"\n       x=\"77.524612\"\n       y=\"2.1602979\" />\n    <rect\n       y=\"16.173868\"\n       x=\"77.396049\"\n       height=\"11.827968\"\n       width=\"13.370747\"\n       id=\"rect4322\"\n       style=\"fill:#c6afe9;fill-opacity:1;stroke:none;stroke-width:1;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\" />\n    <rect\n       style=\"fill:#8d5fd3;fill-opacity:1;stroke:none;stroke-width:1;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4324\"\n       width=\"13.370747\"\n       height=\"11.827968\"\n       x=\"77.396049\"\n       y=\"30.701698\" />\n    <g\n       id=\"g4932\"\n       clip-path=\"none\">\n      <rect\n         y=\"24\"\n         x=\"8\"\n         height=\"34\"\n         width=\"50\"\n         id=\"rect4318\"\n         style=\"fill:#8d5fd3;fill-opacity:1;stroke:none;stroke-width:1;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\" />\n      <path\n         style=\"fill:#c6afe9;fill-opacity:1;stroke:none;stroke-width:1;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n         d=\"M 58,24 8,58 58,58 Z\"\n         id=\"path4329\"\n         inkscape:connector-curvature=\"0\"\n         sodipodi:nodetypes=\"cccc\" />\n      <path\n         sodipodi:nodetypes=\"cccc\"\n         inkscape:connector-curvature=\"0\"\n         id=\"rect4326\"\n         d=\"M 8,24 58,58 8,58 Z\"\n         style=\"opacity:0.75;fill:#ffaaee;fill-opacity:1;stroke:none;stroke-width:1;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n         clip-path=\"none\"\n         mask=\"none\" />\n    </g>\n  </g>\n  <g\n     inkscape:groupmode=\"layer\"\n     id=\"layer2\"\n     inkscape:label=\"Keyhole\"\n     style=\"display:inline\"\n     sodipodi:insensitive=\"true\">\n    <circle\n       style=\"display:inline;opacity:1;fill:#2e1f45;fill-opacity:1;stroke:none;stroke-width:5.28483486;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"path4873\"\n       cx=\"32.59758\"\n       cy=\"38.147919\"\n       r=\"5.6146016\" />\n    <path\n       sodipodi:type=\"star\"\n       style=\"display:inline;opacity:1;fill:#2e1f45;fill-opacity:1;stroke:none;stroke-width:5;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"path4877\"\n       sodipodi:sides=\"3\"\n       sodipodi:cx=\"34.32682\"\n       sodipodi:cy=\"57.571754\"\n       sodipodi:r1=\"11.673945\"\n       sodipodi:r2=\"5.8369727\"\n       sodipodi:arg1=\"0.52359878\"\n       sodipodi:arg2=\"1.5707963\"\n       inkscape:flatsided=\"false\"\n       inkscape:rounded=\"0\"\n       inkscape:randomized=\"0\"\n       d=\"m 44.436754,63.408727 -10.109933,0 -10.109934,0 5.054967,-8.755459 5.054966,-8.755459 5.054967,8.755459 z\"\n       inkscape:transform-center-y=\"-2.3907005\"\n       transform=\"matrix(0.6284863,0,0,0.81915906,11.179559,-2.2339567)\" />\n  </g>\n</svg>\n\x00\x00\x14\xe5<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n<!-- Created with Inkscape (http://www.inkscape.org/) -->\n\n<svg\n   xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n   xmlns:cc=\"http://creativecommons.org/ns#\"\n   xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\"\n   xmlns:svg=\"http://www.w3.org/2000/svg\"\n   xmlns=\"http://www.w3.org/2000/svg\"\n   xmlns:sodipodi=\"http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd\"\n   xmlns:inkscape=\"http://www.inkscape.org/namespaces/inkscape\"\n   width=\"64\"\n   height=\"64\"\n   viewBox=\"0 0 63.999998 63.999998\"\n   id=\"svg2\"\n   version=\"1.1\"\n   inkscape:version=\"0.91 r13725\"\n   sodipodi:docname=\"copy.svg\">\n  <defs\n     id=\"defs4\" />\n  <sodipodi:namedview\n     id=\"base\"\n     pagecolor=\"#ffffff\"\n     bordercolor=\"#666666\"\n     borderopacity=\"1.0\"\n     inkscape:pageopacity=\"0.0\"\n     inkscape:pageshadow=\"2\"\n     inkscape:zoom=\"4\"\n     inkscape:cx=\"63.558065\"\n     inkscape:cy=\"18.758421\"\n     inkscape:document-units=\"px\"\n     inkscape:current-layer=\"layer2\"\n     showgrid=\"false\"\n     units=\"px\"\n     inkscape:window-width=\"2560\"\n     inkscape:window-height=\"1376\"\n     inkscape:window-x=\"0\"\n     inkscape:window-y=\"27\"\n     inkscape:window-maximized=\"1\"\n     showguides=\"true\"\n     inkscape:guide-bbox=\"true\">\n    <sodipodi:guide\n       position=\"31.999999,29.499999\"\n       orientation=\"1,0\"\n       id=\"guide5010\" />\n    <sodipodi:guide\n       position=\"18.124999,31.999999\"\n       orientation=\"0,1\"\n       id=\"guide5012\" />\n    <sodipodi:guide\n       position=\"41.874999,4.8749999\"\n       orientation=\"0,1\"\n       id=\"guide5014\" />\n  </sodipodi:namedview>\n  <metadata\n     id=\"metadata7\">\n    <rdf:RDF>\n      <cc:Work\n         rdf:about=\"\">\n        <dc:format>image/svg+xml</dc:format>\n        <dc:type\n           rdf:resource=\"http://purl.org/dc/dcmitype/StillImage\" />\n        <dc:title></dc:title>\n      </cc:Work>\n    </rdf:RDF>\n  </metadata>\n  <g\n     inkscape:label=\"show\"\n     inkscape:groupmode=\"layer\"\n     id=\"layer1\"\n     transform=\"translate(0,-988.36227)\"\n     style=\"display:none\">\n    <ellipse\n       ry=\"6.3083982\"\n       rx=\"6.7790508\"\n       cy=\"1019.5842\"\n       cx=\"32.603859\"\n       id=\"ellipse4140\"\n       style=\"opacity:1;fill:#000000;fill-opacity:0;stroke:#cd87de;stroke-width:2.90294671;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\" />\n    <text\n       xml:space=\"preserve\"\n       style=\"font-style:normal;font-variant:normal;font-weight:normal;font-stretch:normal;font-size:9.99999905px;line-height:125%;font-family:Arial;-inkscape-font-specification:'Arial, Normal';text-align:center;letter-spacing:0px;word-spacing:0px;writing-mode:lr-tb;text-anchor:middle;fill:#808080;fill-opacity:1;stroke:none;stroke-width:1px;stroke-linecap:butt;stroke-linejoin:miter;stroke-opacity:1\"\n       x=\"32.031895\"\n       y=\"1047.381\"\n       id=\"text4617\"\n       sodipodi:linespacing=\"125%\"><tspan\n         sodipodi:role=\"line\"\n         id=\"tspan4619\"\n         x=\"32.031895\"\n         y=\"1047.381\">SHOW META</tspan></text>\n    <path\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#808080;stroke-width:2.90294671;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"m 46.461845,1021.3096 c -0.05823,2.6148 -6.178925,8.5295 -13.801005,8.5295 -7.62208,0 -13.684556,-6.4387 -13.801005,-8.5295 -0.116441,-2.44 6.178922,-8.5294 13.801005,-8.5294 7.622084,0 13.801004,6.6716 13.801005,8.5294 z\"\n       id=\"path4136\"\n       inkscape:connector-curvature=\"0\"\n       sodipodi:nodetypes=\"cscsc\" />\n  </g>\n  <g\n     inkscape:groupmode=\"layer\"\n     id=\"layer2\"\n     inkscape:label=\"hide\"\n     style=\"display:none\"\n     transform=\"translate(0,-36)\">\n    <path\n       style=\"fill:none;fill-rule:evenodd;stroke:#808080;stroke-width:2.35000491;stroke-linecap:butt;stroke-linejoin:miter;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"M 19.180222,81.51978 45.422658,55.277343\"\n       id=\"path4342\"\n       inkscape:connector-curvature=\"0\" />\n  </g>\n  <g\n     style=\"display:inline\"\n     transform=\"translate(0,-988.36227)\"\n     id=\"g4825\"\n     inkscape:groupmode=\"layer\"\n     inkscape:label=\"copy\">\n    <text\n       sodipodi:linespacing=\"125%\"\n       id=\"text4829\"\n       y=\"1047.3218\"\n       x=\"32.043194\"\n       style=\"font-style:normal;font-variant:normal;font-weight:normal;font-stretch:normal;font-size:9.99999905px;line-height:125%;font-family:Arial;-inkscape-font-specification:'Arial, Normal';text-align:center;letter-spacing:0px;word-spacing:0px;writing-mode:lr-tb;text-anchor:middle;fill:#808080;fill-opacity:1;stroke:none;stroke-width:1px;stroke-linecap:butt;stroke-linejoin:miter;stroke-opacity:1\"\n       xml:space=\"preserve\"><tspan\n         y=\"1047.3218\"\n         x=\"32.043194\"\n         id=\"tspan4831\"\n         sodipodi:role=\"line\">COPY</tspan></text>\n    <rect\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#808080;stroke-width:2.79023433;stroke-linecap:round;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4835\"\n       width=\"20.321836\"\n       height=\"21.70293\"\n       x=\"17.56559\"\n       y=\"997.51721\" />\n    <rect\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#cd87de;stroke-width:2.7023747;stroke-linecap:round;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4837\"\n       width=\"20\"\n       height=\"27\"\n       x=\"26.005533\"\n       y=\"1003.3923\" />\n  </g>\n</svg>\n\x00\x00\x14\xed<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n<!-- Created with Inkscape (http://www.inkscape.org/) -->\n\n<svg\n   xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n   xmlns:cc=\"http://creativecommons.org/ns#\"\n   xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\"\n   xmlns:svg=\"http://www.w3.org/2000/svg\"\n   xmlns=\"http://www.w3.org/2000/svg\"\n   xmlns:sodipodi=\"http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd\"\n   xmlns:inkscape=\"http://www.inkscape.org/namespaces/inkscape\"\n   width=\"64\"\n   height=\"64\"\n   viewBox=\"0 0 63.999998 63.999998\"\n   id=\"svg2\"\n   version=\"1.1\"\n   inkscape:version=\"0.91 r13725\"\n   sodipodi:docname=\"eye_closed.svg\">\n  <defs\n     id=\"defs4\" />\n  <sodipodi:namedview\n     id=\"base\"\n     pagecolor=\"#ffffff\"\n     bordercolor=\"#666666\"\n     borderopacity=\"1.0\"\n     inkscape:pageopacity=\"0.0\"\n     inkscape:pageshadow=\"2\"\n     inkscape:zoom=\"4\"\n     inkscape:cx=\"63.558065\"\n     inkscape:cy=\"18.758421\"\n     inkscape:document-units=\"px\"\n     inkscape:current-layer=\"layer2\"\n     showgrid=\"false\"\n     units=\"px\"\n     inkscape:window-width=\"2560\"\n     inkscape:window-height=\"1376\"\n     inkscape:window-x=\"0\"\n     inkscape:window-y=\"27\"\n     inkscape:window-maximized=\"1\"\n     showguides=\"true\"\n     inkscape:guide-bbox=\"true\">\n    <sodipodi:guide\n       position=\"31.999999,29.499999\"\n       orientation=\"1,0\"\n       id=\"guide5010\" />\n    <sodipodi:guide\n       position=\"18.124999,31.999999\"\n       orientation=\"0,1\"\n       id=\"guide5012\" />\n    <sodipodi:guide\n       position=\"41.874999,4.8749999\"\n       orientation=\"0,1\"\n       id=\"guide5014\" />\n  </sodipodi:namedview>\n  <metadata\n     id=\"metadata7\">\n    <rdf:RDF>\n      <cc:Work\n         rdf:about=\"\">\n        <dc:format>image/svg+xml</dc:format>\n        <dc:type\n           rdf:resource=\"http://purl.org/dc/dcmitype/StillImage\" />\n        <dc:title></dc:title>\n      </cc:Work>\n    </rdf:RDF>\n  </metadata>\n  <g\n     inkscape:label=\"show\"\n     inkscape:groupmode=\"layer\"\n     id=\"layer1\"\n     transform=\"translate(0,-988.36227)\"\n     style=\"display:inline\">\n    <ellipse\n       ry=\"6.3083982\"\n       rx=\"6.7790508\"\n       cy=\"1019.5842\"\n       cx=\"32.603859\"\n       id=\"ellipse4140\"\n       style=\"opacity:1;fill:#000000;fill-opacity:0;stroke:#cd87de;stroke-width:2.90294671;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\" />\n    <text\n       xml:space=\"preserve\"\n       style=\"font-style:normal;font-variant:normal;font-weight:normal;font-stretch:normal;font-size:9.99999905px;line-height:125%;font-family:Arial;-inkscape-font-specification:'Arial, Normal';text-align:center;letter-spacing:0px;word-spacing:0px;writing-mode:lr-tb;text-anchor:middle;fill:#808080;fill-opacity:1;stroke:none;stroke-width:1px;stroke-linecap:butt;stroke-linejoin:miter;stroke-opacity:1\"\n       x=\"32.031895\"\n       y=\"1047.381\"\n       id=\"text4617\"\n       sodipodi:linespacing=\"125%\"><tspan\n         sodipodi:role=\"line\"\n         id=\"tspan4619\"\n         x=\"32.031895\"\n         y=\"1047.381\">SHOW META</tspan></text>\n    <path\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#808080;stroke-width:2.90294671;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"m 46.461845,1021.3096 c -0.05823,2.6148 -6.178925,8.5295 -13.801005,8.5295 -7.62208,0 -13.684556,-6.4387 -13.801005,-8.5295 -0.116441,-2.44 6.178922,-8.5294 13.801005,-8.5294 7.622084,0 13.801004,6.6716 13.801005,8.5294 z\"\n       id=\"path4136\"\n       inkscape:connector-curvature=\"0\"\n       sodipodi:nodetypes=\"cscsc\" />\n  </g>\n  <g\n     inkscape:groupmode=\"layer\"\n     id=\"layer2\"\n     inkscape:label=\"hide\"\n     style=\"display:inline\"\n     transform=\"translate(0,-36)\">\n    <path\n       style=\"fill:none;fill-rule:evenodd;stroke:#808080;stroke-width:2.35000491;stroke-linecap:butt;stroke-linejoin:miter;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"M 19.180222,81.51978 45.422658,55.277343\"\n       id=\"path4342\"\n       inkscape:connector-curvature=\"0\" />\n  </g>\n  <g\n     style=\"display:none\"\n     transform=\"translate(0,-988.36227)\"\n     id=\"g4825\"\n     inkscape:groupmode=\"layer\"\n     inkscape:label=\"copy\">\n    <text\n       sodipodi:linespacing=\"125%\"\n       id=\"text4829\"\n       y=\"1047.3218\"\n       x=\"32.043194\"\n       style=\"font-style:normal;font-variant:normal;font-weight:normal;font-stretch:normal;font-size:9.99999905px;line-height:125%;font-family:Arial;-inkscape-font-specification:'Arial, Normal';text-align:center;letter-spacing:0px;word-spacing:0px;writing-mode:lr-tb;text-anchor:middle;fill:#808080;fill-opacity:1;stroke:none;stroke-width:1px;stroke-linecap:butt;stroke-linejoin:miter;stroke-opacity:1\"\n       xml:space=\"preserve\"><tspan\n         y=\"1047.3218\"\n         x=\"32.043194\"\n         id=\"tspan4831\"\n         sodipodi:role=\"line\">COPY</tspan></text>\n    <rect\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#808080;stroke-width:2.79023433;stroke-linecap:round;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4835\"\n       width=\"20.321836\"\n       height=\"21.70293\"\n       x=\"17.56559\"\n       y=\"997.51721\" />\n    <rect\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#cd87de;stroke-width:2.7023747;stroke-linecap:round;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4837\"\n       width=\"20\"\n       height=\"27\"\n       x=\"26.005533\"\n       y=\"1003.3923\" />\n  </g>\n</svg>\n\x00\x00.\x05import QtQuick 2.7\nimport QtQuick.Controls 1.3\nimport QtQuick.Layouts 1.2\nimport QtQuick.Controls.Styles 1.4\nimport QtQuick.Window 2.2\n\nApplicationWindow {\n    id: rootWindow\n\n    property int margin: 10\n\n    visible: true\n    title: \"GoPass\"\n    width: 800\n    height: 400\n    minimumHeight: 400\n    x: (Screen.width - width) / 2\n    flags: Qt.FramelessWindowHint | Qt.Window\n    color: \"transparent\"\n\n    MouseArea {\n        id: mouseRegion\n        property variant clickPos: \"1,1\"\n        anchors.rightMargin: 0\n        anchors.bottomMargin: 0\n        anchors.leftMargin: 0\n        anchors.topMargin: 0\n\n        anchors.fill: parent;\n\n        onPressed: {\n            clickPos  = Qt.point(mouse.x,mouse.y)\n        }\n\n        onPositionChanged: {\n            var delta = Qt.point(mouse.x-clickPos.x, mouse.y-clickPos.y)\n            rootWindow.x += delta.x;\n            rootWindow.y += delta.y;\n        }\n    }\n\n    Rectangle {\n        id: mainLayout\n\n        color: \"#333\"\n        radius: 10\n        anchors.rightMargin: 0\n        anchors.bottomMargin: 0\n        anchors.leftMargin: 0\n        anchors.topMargin: 0\n        anchors.fill: parent\n        border.width: 2\n        border.color: \"#aaa\"\n\n        RowLayout {\n            id: panes\n\n            anchors.fill: parent\n            anchors.margins: 8\n\n            ColumnLayout {\n                id: leftPane\n                Layout.fillHeight: true\n                Layout.fillWidth: true\n\n                TextField {\n                    id: searchInput\n\n                    height: 42\n                    Layout.fillWidth: true\n                    font.pixelSize: 24\n                    focus: true\n\n                    onTextChanged: ui.query(text)\n                    onAccepted: passwords.copyToClipboard(hitList.currentIndex)\n                    textColor: \"white\"\n                    style: TextFieldStyle {\n                        textColor: \"white\"\n                        background: Rectangle {\n                            radius: 5\n                            border.color: \"#666\"\n                            border.width: 1\n                            color: \"#333\"\n                        }\n                    }\n                }\n\n                ScrollView{\n                    id: resultsContainer\n\n                    Layout.fillHeight: true\n                    Layout.fillWidth: true\n                    style: ScrollViewStyle{\n                        transientScrollBars: true\n                        scrollToClickedPosition : true\n                    }\n\n                    ListView {\n                        id: hitList\n\n                        anchors.fill: parent\n                        focus: true\n                        interactive: true\n                        model: passwords.len\n                        delegate: passwordEntry\n                        highlight: Rectangle {\n                            color: \"#444\"\n                            radius: 3\n                            anchors.left: parent ? parent.left : undefined\n                            anchors.right: parent ? parent.right : undefined\n                        }\n                        onCurrentItemChanged:{\n                            passwords.select(currentIndex)\n                        }\n                    }\n                }\n\n                Text {\n                    id: status\n                    Layout.fillWidth: true\n                    text: ui.status\n                    z: -1\n                    height: 14\n                    font.pixelSize: 14\n                    color: \"#aaa\"\n                }\n            }\n\n            Rectangle {\n                id: frame\n                width: 300;\n                Layout.fillHeight: true\n                color: \"#444\"\n                border.color: \"#333\"\n                border.width: 2\n                radius: 10\n\n                ColumnLayout {\n                    id: rightPane\n                    anchors.fill: parent\n                    spacing: 2\n                    Item {\n                        id: logoBox\n\n                        Layout.minimumHeight: 100\n                        Layout.maximumHeight: 100\n                        Layout.fillWidth: true\n                        MouseArea{\n                            onClicked: ui.toggleShowMetadata()\n                            width: 48; height: 48\n                            anchors.left: parent.left\n                            anchors.leftMargin: 25\n                            anchors.verticalCenter: parent.verticalCenter\n                        Image{\n                            id: metadataToggle\n                            width: 48; height: 48\n                            fillMode: Image.PreserveAspectFit\n                            source: ui.showMetadata ? \"eye_open.svg\": \"eye_closed.svg\"\n\n                        }\n                        }\n                        Image {\n                            id: logo\n                            width: 48\n                            height: 48\n                            anchors.verticalCenter: parent.verticalCenter\n                            anchors.horizontalCenter: parent.horizontalCenter\n                            anchors.topMargin: 24\n                            fillMode: Image.PreserveAspectFit\n                            source: \"logo.svg\"\n                        }\n\n                        Image{\n                            id: copyIcon\n                            width: 48; height: 48\n                            anchors.right: parent.right\n                            anchors.rightMargin: 25\n                            fillMode: Image.PreserveAspectFit\n                            source: \"copy.svg\"\n                            anchors.verticalCenter: parent.verticalCenter\n\n                        }\n\n                        Canvas{\n                            id: progress\n                            property double countdown: ui.countdown\n                            property bool cached: ui.password.cached\n\n                            width: 100; height: 100\n                            anchors.verticalCenter: parent.verticalCenter\n                            anchors.horizontalCenter: parent.horizontalCenter\n                            visible: true\n                            contextType: \"2d\"\n\n                            onCountdownChanged: progress.requestPaint()\n                            onPaint: {\n                                var top = 3.0*(Math.PI/2.0)\n                                var cx = 50, cy = 50, r = 40, lw = 5\n                                var p = (countdown/15.0)\n                                context.reset()\n                                context.lineWidth = lw\n                                context.strokeStyle = cached?\"#666\":\"#966\"\n                                context.arc(cx, cy, r, 0, 2.0*Math.PI , false)\n                                context.stroke()\n\n                                context.beginPath()\n                                context.strokeStyle = \"#a6a\"\n                                context.lineWidth = lw\n                                context.arc(cx, cy, r, top, top-p*2.0*Math.PI, false)\n                                context.stroke()\n                            }\n                        }\n                    }\n                    Text{\n                        Layout.fillWidth: true;\n                        anchors.horizontalCenter: parent.horizontalCenter\n                        horizontalAlignment: Text.AlignHCenter\n                        font.pixelSize: 18\n                        text: ui.password.name \n                        color: \"#eee\"\n                    }\n                    Rectangle {\n                        id: rectangle1\n                        height: 24\n                        color: \"#555\"\n                        border.color: \"#444\"\n                        border.width: 2\n                        radius: 10\n                        Layout.fillHeight: false\n                        Layout.fillWidth: true\n                        Layout.maximumHeight: 24\n                        Layout.minimumHeight: 24\n                        Layout.margins: 5\n                        Text{\n                            id: info\n                            horizontalAlignment: Text.AlignHCenter\n                            color: \"#aaa\"\n                            padding: 5\n                            font.pixelSize: 10\n                            text: ui.password.info\n                            anchors.horizontalCenter: parent.horizontalCenter\n                       }\n                    }\n\n                    /*\n                    RowLayout {\n                        id: rowLayout1\n                        width: 100\n                        height: 100\n                        Layout.fillWidth: true\n                        anchors.horizontalCenter: parent.horizontalCenter\n                        \n                        RoundButton {\n                            label: \"COPY\"      \n                            onClicked: passwords.copyToClipboard(hitList.currentIndex)\n                        }\n                        RoundButton {\n                            label: \"SHOW\"      \n                            onClicked: ui.toggleShowMetadata()\n                        }\n                        \n                    }\n*/\n                    ScrollView {\n                        id: metadataContainer\n                        Layout.fillHeight: true\n                        Layout.fillWidth: true\n                        Layout.margins: 10\n                        style: ScrollViewStyle{\n                            transientScrollBars: true\n                            scrollToClickedPosition : true\n                        }\n                        TextEdit {\n                            id: metadata\n                            width: 270\n//                            anchors.fill: metadataContainer\n                            selectByMouse: true\n                            readOnly: true\n                            font.pixelSize: 12\n                            font.family: \"Courier\"\n                            color: \"white\"\n                            selectionColor: \"#666\"\n                            text: ui.password.metadata\n                            wrapMode: TextEdit.WrapAnywhere\n                        }\n                    }\n                }\n            }\n        }\n\n\n        Shortcut {\n            sequence:\"Ctrl+K\"\n            context: Qt.ApplicationShortcut\n            onActivated: hitList.decrementCurrentIndex()\n        }\n\n        Shortcut {\n            sequence:\"Up\"\n            context: Qt.ApplicationShortcut\n            onActivated: hitList.decrementCurrentIndex()\n        }\n\n        Shortcut {\n            sequence:\"Ctrl+j\"\n            onActivated: hitList.incrementCurrentIndex()\n        }\n\n        Shortcut {\n            sequence:\"Down\"\n            onActivated: hitList.incrementCurrentIndex()\n        }\n\n        Shortcut {\n            sequence:\"Ctrl+r\"\n            onActivated: ui.toggleShowMetadata()\n        }\n\n        Shortcut {\n            sequence:\"Ctrl+l\"\n            onActivated: {searchInput.selectAll(); searchInput.focus=true}\n        }\n\n        Shortcut {\n            sequence: \"Esc\"\n            context: Qt.ApplicationShortcut\n            onActivated: ui.quit()\n        }\n\n        Component {\n            id: passwordEntry\n\n            Text {\n                property var view: ListView.view\n                property int itemIndex: index\n\n                text: passwords.get(index).name;\n                font.pixelSize: 18\n                color: ListView.isCurrentItem? \"#dd00bb\":\"gray\"\n\n                MouseArea{\n                    anchors.fill: parent\n                    onClicked: view.currentIndex = itemIndex\n                }\n            }\n        }\n    }\n}\n\x00\x00\x14\xe9<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n<!-- Created with Inkscape (http://www.inkscape.org/) -->\n\n<svg\n   xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n   xmlns:cc=\"http://creativecommons.org/ns#\"\n   xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\"\n   xmlns:svg=\"http://www.w3.org/2000/svg\"\n   xmlns=\"http://www.w3.org/2000/svg\"\n   xmlns:sodipodi=\"http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd\"\n   xmlns:inkscape=\"http://www.inkscape.org/namespaces/inkscape\"\n   width=\"64\"\n   height=\"64\"\n   viewBox=\"0 0 63.999998 63.999998\"\n   id=\"svg2\"\n   version=\"1.1\"\n   inkscape:version=\"0.91 r13725\"\n   sodipodi:docname=\"eye_open.svg\">\n  <defs\n     id=\"defs4\" />\n  <sodipodi:namedview\n     id=\"base\"\n     pagecolor=\"#ffffff\"\n     bordercolor=\"#666666\"\n     borderopacity=\"1.0\"\n     inkscape:pageopacity=\"0.0\"\n     inkscape:pageshadow=\"2\"\n     inkscape:zoom=\"4\"\n     inkscape:cx=\"63.558065\"\n     inkscape:cy=\"18.758421\"\n     inkscape:document-units=\"px\"\n     inkscape:current-layer=\"layer2\"\n     showgrid=\"false\"\n     units=\"px\"\n     inkscape:window-width=\"2560\"\n     inkscape:window-height=\"1376\"\n     inkscape:window-x=\"0\"\n     inkscape:window-y=\"27\"\n     inkscape:window-maximized=\"1\"\n     showguides=\"true\"\n     inkscape:guide-bbox=\"true\">\n    <sodipodi:guide\n       position=\"31.999999,29.499999\"\n       orientation=\"1,0\"\n       id=\"guide5010\" />\n    <sodipodi:guide\n       position=\"18.124999,31.999999\"\n       orientation=\"0,1\"\n       id=\"guide5012\" />\n    <sodipodi:guide\n       position=\"41.874999,4.8749999\"\n       orientation=\"0,1\"\n       id=\"guide5014\" />\n  </sodipodi:namedview>\n  <metadata\n     id=\"metadata7\">\n    <rdf:RDF>\n      <cc:Work\n         rdf:about=\"\">\n        <dc:format>image/svg+xml</dc:format>\n        <dc:type\n           rdf:resource=\"http://purl.org/dc/dcmitype/StillImage\" />\n        <dc:title></dc:title>\n      </cc:Work>\n    </rdf:RDF>\n  </metadata>\n  <g\n     inkscape:label=\"show\"\n     inkscape:groupmode=\"layer\"\n     id=\"layer1\"\n     transform=\"translate(0,-988.36227)\"\n     style=\"display:inline\">\n    <ellipse\n       ry=\"6.3083982\"\n       rx=\"6.7790508\"\n       cy=\"1019.5842\"\n       cx=\"32.603859\"\n       id=\"ellipse4140\"\n       style=\"opacity:1;fill:#000000;fill-opacity:0;stroke:#cd87de;stroke-width:2.90294671;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\" />\n    <text\n       xml:space=\"preserve\"\n       style=\"font-style:normal;font-variant:normal;font-weight:normal;font-stretch:normal;font-size:9.99999905px;line-height:125%;font-family:Arial;-inkscape-font-specification:'Arial, Normal';text-align:center;letter-spacing:0px;word-spacing:0px;writing-mode:lr-tb;text-anchor:middle;fill:#808080;fill-opacity:1;stroke:none;stroke-width:1px;stroke-linecap:butt;stroke-linejoin:miter;stroke-opacity:1\"\n       x=\"32.031895\"\n       y=\"1047.381\"\n       id=\"text4617\"\n       sodipodi:linespacing=\"125%\"><tspan\n         sodipodi:role=\"line\"\n         id=\"tspan4619\"\n         x=\"32.031895\"\n         y=\"1047.381\">SHOW META</tspan></text>\n    <path\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#808080;stroke-width:2.90294671;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"m 46.461845,1021.3096 c -0.05823,2.6148 -6.178925,8.5295 -13.801005,8.5295 -7.62208,0 -13.684556,-6.4387 -13.801005,-8.5295 -0.116441,-2.44 6.178922,-8.5294 13.801005,-8.5294 7.622084,0 13.801004,6.6716 13.801005,8.5294 z\"\n       id=\"path4136\"\n       inkscape:connector-curvature=\"0\"\n       sodipodi:nodetypes=\"cscsc\" />\n  </g>\n  <g\n     inkscape:groupmode=\"layer\"\n     id=\"layer2\"\n     inkscape:label=\"hide\"\n     style=\"display:none\"\n     transform=\"translate(0,-36)\">\n    <path\n       style=\"fill:none;fill-rule:evenodd;stroke:#808080;stroke-width:2.35000491;stroke-linecap:butt;stroke-linejoin:miter;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       d=\"M 19.180222,81.51978 45.422658,55.277343\"\n       id=\"path4342\"\n       inkscape:connector-curvature=\"0\" />\n  </g>\n  <g\n     style=\"display:none\"\n     transform=\"translate(0,-988.36227)\"\n     id=\"g4825\"\n     inkscape:groupmode=\"layer\"\n     inkscape:label=\"copy\">\n    <text\n       sodipodi:linespacing=\"125%\"\n       id=\"text4829\"\n       y=\"1047.3218\"\n       x=\"32.043194\"\n       style=\"font-style:normal;font-variant:normal;font-weight:normal;font-stretch:normal;font-size:9.99999905px;line-height:125%;font-family:Arial;-inkscape-font-specification:'Arial, Normal';text-align:center;letter-spacing:0px;word-spacing:0px;writing-mode:lr-tb;text-anchor:middle;fill:#808080;fill-opacity:1;stroke:none;stroke-width:1px;stroke-linecap:butt;stroke-linejoin:miter;stroke-opacity:1\"\n       xml:space=\"preserve\"><tspan\n         y=\"1047.3218\"\n         x=\"32.043194\"\n         id=\"tspan4831\"\n         sodipodi:role=\"line\">COPY</tspan></text>\n    <rect\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#808080;stroke-width:2.79023433;stroke-linecap:round;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4835\"\n       width=\"20.321836\"\n       height=\"21.70293\"\n       x=\"17.56559\"\n       y=\"997.51721\" />\n    <rect\n       style=\"opacity:1;fill:none;fill-opacity:1;stroke:#cd87de;stroke-width:2.7023747;stroke-linecap:round;stroke-miterlimit:4;stroke-dasharray:none;stroke-opacity:1\"\n       id=\"rect4837\"\n       width=\"20\"\n       height=\"27\"\n       x=\"26.005533\"\n       y=\"1003.3923\" />\n  </g>\n</svg>\n\x00\x06\x06\x8a\x9c\xb3\x00a\x00s\x00s\x00e\x00t\x00s\x00\x0f\x03\xf1\f\xfc\x00R\x00o\x00u\x00n\x00d\x00B\x00u\x00t\x00t\x00o\x00n\x00.\x00q\x00m\x00l\x00\b\x05\xe2T\xa7\x00l\x00o\x00g\x00o\x00.\x00s\x00v\x00g\x00\b\x06|W\x87\x00c\x00o\x00p\x00y\x00.\x00s\x00v\x00g\x00\x0e\x06\xdc\a'\x00e\x00y\x00e\x00_\x00c\x00l\x00o\x00s\x00e\x00d\x00.\x00s\x00v\x00g\x00\b\b\x01Z\\\x00m\x00a\x00i\x00n\x00.\x00q\x00m\x00l\x00\f\v^6\a\x00e\x00y\x00e\x00_\x00o\x00p\x00e\x00n\x00.\x00s\x00v\x00g\x00\x00\x00\x00\x00\x02\x00\x00\x00\x01\x00\x00\x00\x01\x00\x00\x00\x00\x00\x02\x00\x00\x00\x06\x00\x00\x00\x02\x00\x00\x00\x12\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x006\x00\x00\x00\x00\x00\x01\x00\x00\x02\xfe\x00\x00\x00L\x00\x00\x00\x00\x00\x01\x00\x00\x19\xf6\x00\x00\x00b\x00\x00\x00\x00\x00\x01\x00\x00.\xdf\x00\x00\x00\x84\x00\x00\x00\x00\x00\x01\x00\x00C\xd0\x00\x00\x00\x9a\x00\x00\x00\x00\x00\x01\x00\x00q\xd9"
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// queryFields are the filters the search box knows, like user:alice
+var queryFields = map[string]bool{
+	"folder":    true,
+	"user":      true,
+	"url":       true,
+	"recipient": true,
+	"has":       true,
+	"tag":       true,
+}
+
+// queryTerm is a part of a query, like work, "two words", -archive or
+// url:github
+type queryTerm struct {
+	field  string
+	value  string
+	negate bool
+}
+
+// parseQuery splits a query into terms, which all have to match
+func parseQuery(q string) ([]queryTerm, error) {
+	var terms []queryTerm
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		var t queryTerm
+		if q[0] == '-' {
+			t.negate = true
+			q = q[1:]
+		}
+		// A field name is only a field name when it's followed by a colon.
+		// Other words before a colon are searched for like any text, so
+		// https://example.com or 10:30 can be found.
+		if i := strings.IndexAny(q, ` :"`); i > 0 && q[i] == ':' && queryFields[strings.ToLower(q[:i])] {
+			t.field = strings.ToLower(q[:i])
+			q = q[i+1:]
+		}
+		if strings.HasPrefix(q, `"`) {
+			end := strings.Index(q[1:], `"`)
+			if end < 0 {
+				return nil, errors.New("Missing closing quote")
+			}
+			t.value, q = q[1:end+1], q[end+2:]
+		} else {
+			end := strings.IndexAny(q, " \t")
+			if end < 0 {
+				end = len(q)
+			}
+			t.value, q = q[:end], q[end:]
+		}
+		t.value = strings.ToLower(t.value)
+		if t.value == "" {
+			switch {
+			case t.field != "":
+				return nil, fmt.Errorf("Missing value after %s:", t.field)
+			case t.negate:
+				return nil, errors.New("Missing term after -")
+			}
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+// metadataIndex looks up metadata of passwords that isn't secret, like
+// user names and URLs, without decrypting them
+type metadataIndex interface {
+	// Lookup returns the fields of the named password, keyed by
+	// lowercase field name, nil if it isn't indexed
+	Lookup(name string) map[string]string
+}
+
+// matchTerms checks if a password matches all terms, returning where
+func (ps *PasswordStore) matchTerms(terms []queryTerm, p Password) ([]Span, bool) {
+	var spans []Span
+	for _, t := range terms {
+		span, ok := ps.matchTerm(t, p)
+		if ok == t.negate {
+			return nil, false
+		}
+		if ok && span != nil {
+			spans = append(spans, *span)
+		}
+	}
+	return spans, true
+}
+
+// matchTerm checks if a password matches a single term, ignoring negation
+func (ps *PasswordStore) matchTerm(t queryTerm, p Password) (*Span, bool) {
+	switch t.field {
+	case "":
+		return matchName(t.value, p.Name)
+	case "folder":
+		return matchFolder(t.value, p.Name)
+	case "recipient":
+		return nil, ps.matchRecipient(t.value, p)
+	case "has":
+		fields := ps.metadata(p)
+		if _, ok := fields[t.value]; ok {
+			return &Span{Term: "has " + t.value, Source: MatchMetadata}, true
+		}
+		if t.value == "otp" {
+			for key, value := range fields {
+				if key == "totp" || strings.HasPrefix(value, "otpauth://") {
+					return &Span{Term: "has otp", Source: MatchMetadata}, true
+				}
+			}
+		}
+		return nil, false
+	case "tag":
+		for _, tag := range tagsOf(ps.metadata(p)) {
+			if tag == t.value {
+				return &Span{Term: "tag " + tag, Source: MatchMetadata}, true
+			}
+		}
+		return nil, false
+	}
+	value, ok := ps.metadata(p)[t.field]
+	if !ok || !strings.Contains(strings.ToLower(value), t.value) {
+		return nil, false
+	}
+	return &Span{Term: t.field + ": " + value, Source: MatchMetadata}, true
+}
+
+func (ps *PasswordStore) metadata(p Password) map[string]string {
+	if ps.index == nil {
+		return nil
+	}
+	return ps.index.Lookup(p.Name)
+}
+
+// matchName checks if the term is in the name
+func matchName(term, name string) (*Span, bool) {
+	lower := strings.ToLower(name)
+	i := strings.Index(lower, term)
+	if i < 0 {
+		return nil, false
+	}
+	// Lowercasing can change the length of some characters, and then the
+	// offsets wouldn't fit the name
+	if len(lower) != len(name) || term == "" {
+		return nil, true
+	}
+	source := MatchName
+	if i < strings.LastIndex(name, "/")+1 {
+		source = MatchFolder
+	}
+	return &Span{Start: i, End: i + len(term), Term: term, Source: source}, true
+}
+
+// matchFolder checks if the password is in a folder with the given name,
+// or path of folders, at any depth
+func matchFolder(folder, name string) (*Span, bool) {
+	dir := parentDir(name)
+	folder = strings.Trim(folder, "/")
+	i := strings.Index("/"+strings.ToLower(dir)+"/", "/"+folder+"/")
+	if i < 0 || len(dir) != len(strings.ToLower(dir)) {
+		return nil, i >= 0
+	}
+	return &Span{Start: i, End: i + len(folder), Term: "folder:" + folder, Source: MatchFolder}, true
+}
+
+// matchRecipient checks if the password is encrypted to a key, given as
+// a key ID or fingerprint, or part of a user ID
+func (ps *PasswordStore) matchRecipient(recipient string, p Password) bool {
+	keyIDs, err := p.KeyIDs()
+	if err != nil {
+		return false
+	}
+	hexID := strings.ToUpper(strings.TrimPrefix(recipient, "0x"))
+	for _, id := range keyIDs {
+		if len(hexID) >= 8 && strings.HasSuffix(fmt.Sprintf("%016X", id), hexID) {
+			return true
+		}
+		keys, _ := backend.KeysById(id)
+		for _, k := range keys {
+			if len(hexID) >= 8 && strings.HasSuffix(fmt.Sprintf("%X", k.Fingerprint), hexID) {
+				return true
+			}
+			for _, uid := range k.UserIDs() {
+				if strings.Contains(strings.ToLower(uid), recipient) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	for q, want := range map[string][]queryTerm{
+		"":                   nil,
+		"Work mail":          {{value: "work"}, {value: "mail"}},
+		`"old mail" -spam`:   {{value: "old mail"}, {value: "spam", negate: true}},
+		"User:Alice":         {{field: "user", value: "alice"}},
+		`-folder:"old work"`: {{field: "folder", value: "old work", negate: true}},
+		"tag:x has:otp":      {{field: "tag", value: "x"}, {field: "has", value: "otp"}},
+		// Words with colons that aren't filters are plain text
+		"https://example.com/login": {{value: "https://example.com/login"}},
+		"10:30 -note:x":             {{value: "10:30"}, {value: "note:x", negate: true}},
+	} {
+		got, err := parseQuery(q)
+		if err != nil {
+			t.Errorf("%q: %v", q, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q parsed as %+v, want %+v", q, got, want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, q := range []string{`"open`, "user:", "-", `url:""`} {
+		if terms, err := parseQuery(q); err == nil {
+			t.Errorf("%q parsed as %+v", q, terms)
+		}
+	}
+}
+
+// mapIndex is a metadataIndex kept in memory
+type mapIndex map[string]map[string]string
+
+func (idx mapIndex) Lookup(name string) map[string]string {
+	return idx[name]
+}
+
+func TestQuery(t *testing.T) {
+	store := &PasswordStore{
+		passwords: []Password{
+			{Name: "Work/Mail/Gmail"},
+			{Name: "Work/Git"},
+			{Name: "Home/Bank"},
+			{Name: "Mail"},
+		},
+		index: mapIndex{
+			"Work/Git":  {"user": "Alice", "tags": "work, shared"},
+			"Home/Bank": {"user": "bob", "otp": "", "tags": "money"},
+		},
+	}
+	for _, tc := range []struct {
+		query  string
+		names  []string
+		spans  [][]Span
+		reason []string
+	}{
+		{
+			query:  "gmail",
+			names:  []string{"Work/Mail/Gmail"},
+			spans:  [][]Span{{{Start: 10, End: 15, Term: "gmail", Source: MatchName}}},
+			reason: []string{"in name"},
+		},
+		{
+			// Found in the folder of one, and the name of the other
+			query: "mail",
+			names: []string{"Work/Mail/Gmail", "Mail"},
+			spans: [][]Span{
+				{{Start: 5, End: 9, Term: "mail", Source: MatchFolder}},
+				{{Start: 0, End: 4, Term: "mail", Source: MatchName}},
+			},
+			reason: []string{"in folder", "in name"},
+		},
+		{
+			query:  "folder:work/mail",
+			names:  []string{"Work/Mail/Gmail"},
+			spans:  [][]Span{{{Start: 0, End: 9, Term: "folder:work/mail", Source: MatchFolder}}},
+			reason: []string{"in folder"},
+		},
+		{
+			query:  "user:ali",
+			names:  []string{"Work/Git"},
+			spans:  [][]Span{{{Term: "user: Alice", Source: MatchMetadata}}},
+			reason: []string{"in metadata"},
+		},
+		{
+			query:  "has:otp",
+			names:  []string{"Home/Bank"},
+			spans:  [][]Span{{{Term: "has otp", Source: MatchMetadata}}},
+			reason: []string{"in metadata"},
+		},
+		{
+			query: "tag:shared git",
+			names: []string{"Work/Git"},
+			spans: [][]Span{{
+				{Term: "tag shared", Source: MatchMetadata},
+				{Start: 5, End: 8, Term: "git", Source: MatchName},
+			}},
+			reason: []string{"in metadata and name"},
+		},
+		{
+			// Negated terms don't say why something matched
+			query:  "work -mail",
+			names:  []string{"Work/Git"},
+			spans:  [][]Span{{{Start: 0, End: 4, Term: "work", Source: MatchFolder}}},
+			reason: []string{"in folder"},
+		},
+		{
+			query: "tag:nope",
+		},
+	} {
+		hits, err := store.Query(tc.query)
+		if err != nil {
+			t.Errorf("%q: %v", tc.query, err)
+			continue
+		}
+		var names, reasons []string
+		var spans [][]Span
+		for _, h := range hits {
+			names = append(names, h.Name)
+			spans = append(spans, h.Spans)
+			reasons = append(reasons, h.Reason())
+		}
+		if !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("%q found %q, want %q", tc.query, names, tc.names)
+			continue
+		}
+		if !reflect.DeepEqual(spans, tc.spans) {
+			t.Errorf("%q matched at %+v, want %+v", tc.query, spans, tc.spans)
+		}
+		if !reflect.DeepEqual(reasons, tc.reason) {
+			t.Errorf("%q found %q, want %q", tc.query, reasons, tc.reason)
+		}
+	}
+}
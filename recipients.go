@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Recipient is a key that a password is, or should be, encrypted to
+type Recipient struct {
+	KeyID  string
+	UserID string
+	// Missing is set when the key is listed in .gpg-id,
+	// but the password is not encrypted to it
+	Missing bool
+	// Extra is set when the password is encrypted to the key,
+	// but it is not listed in .gpg-id
+	Extra bool
+}
+
+func (r Recipient) String() string {
+	mark := " "
+	switch {
+	case r.Missing:
+		mark = "-"
+	case r.Extra:
+		mark = "+"
+	}
+	s := mark + " " + r.KeyID
+	if r.UserID != "" {
+		s += " " + r.UserID
+	}
+	return s
+}
+
+// gpgIDFile finds the .gpg-id file governing a password, which is the one
+// closest to it in the directory tree
+func (ps *PasswordStore) gpgIDFile(p Password) (string, error) {
+	for dir := filepath.Dir(p.Path); strings.HasPrefix(dir, ps.Prefix); {
+		f := filepath.Join(dir, ".gpg-id")
+		if _, err := os.Stat(f); err == nil {
+			return f, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", fmt.Errorf("No .gpg-id found for %s", p.Name)
+}
+
+// readGPGID reads the key IDs, fingerprints or email addresses in a .gpg-id file
+func readGPGID(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, scanner.Err()
+}
+
+// matchesGPGID checks if a key is the one meant by a line in .gpg-id,
+// which is either a key ID or fingerprint, an email address, or a whole
+// user ID. Email addresses have to be the one in the user ID exactly, so
+// bob@example.com doesn't match alice.bob@example.com.
+func matchesGPGID(k *pubKey, id string) bool {
+	if hexID := strings.ToUpper(strings.TrimPrefix(id, "0x")); isHexID(hexID) {
+		for _, key := range []*pubKey{k, k.Primary()} {
+			if strings.HasSuffix(fmt.Sprintf("%X", key.Fingerprint), hexID) {
+				return true
+			}
+		}
+		return false
+	}
+	email := strings.Trim(id, "<>")
+	for _, uid := range k.UserIDs() {
+		if strings.EqualFold(uid, id) || strings.EqualFold(userIDEmail(uid), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHexID tells if s looks like a key ID or fingerprint
+func isHexID(s string) bool {
+	if len(s) < 8 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789ABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// userIDEmail is the email address in a user ID like
+// "Alice <alice@example.com>", or the user ID if it's only an address
+func userIDEmail(uid string) string {
+	if i := strings.LastIndex(uid, "<"); i >= 0 {
+		if j := strings.Index(uid[i:], ">"); j > 0 {
+			return uid[i+1 : i+j]
+		}
+	}
+	return strings.TrimSpace(uid)
+}
+
+// Recipients lists every key a password is encrypted to, and every key it
+// should have been encrypted to according to its .gpg-id
+func (ps *PasswordStore) Recipients(p Password) ([]Recipient, error) {
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	keyIDs, err := backend.RecipientsOf(file)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := backend.PublicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var gpgIDs []string
+	if f, err := ps.gpgIDFile(p); err == nil {
+		if gpgIDs, err = readGPGID(f); err != nil {
+			return nil, err
+		}
+	}
+
+	var recipients []Recipient
+	found := make(map[string]bool)
+	for _, keyID := range keyIDs {
+		r := Recipient{KeyID: fmt.Sprintf("%016X", keyID), Extra: true}
+		matching, err := backend.KeysById(keyID)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range matching {
+			if uids := k.UserIDs(); len(uids) > 0 {
+				r.UserID = uids[0]
+			}
+			for _, id := range gpgIDs {
+				if matchesGPGID(k, id) {
+					found[id] = true
+					r.Extra = false
+				}
+			}
+		}
+		recipients = append(recipients, r)
+	}
+
+	for _, id := range gpgIDs {
+		if found[id] {
+			continue
+		}
+		r := Recipient{KeyID: id, Missing: true}
+		for _, k := range keys {
+			if k.primary == nil && matchesGPGID(k, id) {
+				r.KeyID = fmt.Sprintf("%016X", k.KeyID)
+				if uids := k.UserIDs(); len(uids) > 0 {
+					r.UserID = uids[0]
+				}
+				break
+			}
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// newRecipientsTestCrypto makes a keyring with a key for each email
+func newRecipientsTestCrypto(t *testing.T, emails ...string) (map[string]*openpgp.Entity, *openpgpCrypto) {
+	t.Helper()
+	entities := make(map[string]*openpgp.Entity)
+	var keyring bytes.Buffer
+	for _, email := range emails {
+		e, path := newTestKeyring(t, email)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyring.Write(data)
+		entities[email] = e
+	}
+	path := filepath.Join(t.TempDir(), "secring.gpg")
+	if err := ioutil.WriteFile(path, keyring.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newOpenPGPCrypto(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entities, c
+}
+
+func TestMatchesGPGID(t *testing.T) {
+	entities, c := newRecipientsTestCrypto(t, "alice.bob@example.com")
+	e := entities["alice.bob@example.com"]
+	keys, err := c.KeysById(e.Subkeys[0].PublicKey.KeyId)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("got %v, %v", keys, err)
+	}
+	sub := keys[0]
+	for id, want := range map[string]bool{
+		"alice.bob@example.com":        true,
+		"<Alice.Bob@example.com>":      true,
+		"Test <alice.bob@example.com>": true,
+		"bob@example.com":              false,
+		"bob@x":                        false,
+		"Test":                         false,
+		fmt.Sprintf("%X", e.PrimaryKey.Fingerprint):        true,
+		fmt.Sprintf("0x%016X", e.PrimaryKey.KeyId):         true,
+		fmt.Sprintf("%016X", e.Subkeys[0].PublicKey.KeyId): true,
+		fmt.Sprintf("%08X", uint32(e.PrimaryKey.KeyId)):    true,
+		"0123456789ABCDEF": false,
+	} {
+		if got := matchesGPGID(sub, id); got != want {
+			t.Errorf("matchesGPGID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	entities, c := newRecipientsTestCrypto(t, "alice@example.com", "bob@example.com", "alice.bob@example.com")
+	defer func(b Crypto) { backend = b }(backend)
+	backend = c
+
+	store := &PasswordStore{Prefix: t.TempDir()}
+	// bob@example.com is listed, but the password is encrypted to
+	// alice.bob@example.com instead
+	gpgID := "alice@example.com\nbob@example.com\n"
+	if err := ioutil.WriteFile(filepath.Join(store.Prefix, ".gpg-id"), []byte(gpgID), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pw := Password{Name: "Mail", Path: filepath.Join(store.Prefix, "Mail.gpg")}
+	ciphertext := encryptTest(t, c, "x", "alice@example.com", "alice.bob@example.com")
+	if err := ioutil.WriteFile(pw.Path, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	recipients, err := store.Recipients(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Recipient{
+		{KeyID: fmt.Sprintf("%016X", entities["alice@example.com"].Subkeys[0].PublicKey.KeyId), UserID: "Test <alice@example.com>"},
+		{KeyID: fmt.Sprintf("%016X", entities["alice.bob@example.com"].Subkeys[0].PublicKey.KeyId), UserID: "Test <alice.bob@example.com>", Extra: true},
+		{KeyID: fmt.Sprintf("%016X", entities["bob@example.com"].PrimaryKey.KeyId), UserID: "Test <bob@example.com>", Missing: true},
+	}
+	if len(recipients) != len(want) {
+		t.Fatalf("got %v, want %v", recipients, want)
+	}
+	for i := range want {
+		if recipients[i] != want[i] {
+			t.Errorf("recipient %d is %+v, want %+v", i, recipients[i], want[i])
+		}
+	}
+
+	// A key listed in .gpg-id that isn't in the keyring at all
+	os.Remove(filepath.Join(store.Prefix, ".gpg-id"))
+	sub := filepath.Join(store.Prefix, "Work")
+	os.Mkdir(sub, 0700)
+	ioutil.WriteFile(filepath.Join(sub, ".gpg-id"), []byte("alice@example.com\ncarol@example.com\n"), 0600)
+	pw = Password{Name: "Work/Git", Path: filepath.Join(sub, "Git.gpg")}
+	ioutil.WriteFile(pw.Path, encryptTest(t, c, "x", "alice@example.com"), 0600)
+	recipients, err = store.Recipients(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 2 || recipients[0].Extra || recipients[0].Missing ||
+		recipients[1] != (Recipient{KeyID: "carol@example.com", Missing: true}) {
+		t.Errorf("got %+v", recipients)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/limetext/qml-go"
+)
+
+// mask is shown instead of the value of a secret field
+const mask = "••••••••"
+
+// secretKeys are the parts of a metadata key that make its value secret
+var secretKeys = []string{"pass", "pin", "secret", "otp", "token", "key", "cvv", "cvc", "answer", "recovery"}
+
+// revealTimeout is how long decrypted metadata stays visible, set with
+// GOPASS_REVEAL_TIMEOUT, 0 keeps it until it is hidden
+func revealTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("GOPASS_REVEAL_TIMEOUT"))
+	if err != nil {
+		return 30 * time.Second
+	}
+	return timeout
+}
+
+// Field is a line of metadata as shown in the UI, split in key and value
+// when it looks like "key: value"
+type Field struct {
+	Key   string
+	Value string
+	// Secret fields are masked until they are revealed one by one
+	Secret bool
+	Hidden bool
+}
+
+// field is a Field that still points into the decrypted metadata, so it
+// only becomes a string while it's shown
+type field struct {
+	key, value     []byte
+	secret, hidden bool
+}
+
+// parseFields splits metadata into fields
+func parseFields(metadata []byte) []field {
+	var fields []field
+	if len(bytes.TrimSpace(metadata)) == 0 {
+		return nil
+	}
+	for _, line := range bytes.Split(bytes.TrimRight(metadata, "\n"), []byte("\n")) {
+		var f field
+		if i := bytes.Index(line, []byte(": ")); i > 0 {
+			f.key, f.value = line[:i], line[i+2:]
+		} else {
+			f.value = line
+		}
+		f.secret = isSecretKey(string(f.key)) || bytes.HasPrefix(f.value, []byte("otpauth://"))
+		f.hidden = f.secret
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Revealed is the model for the decrypted metadata of the selected password
+type Revealed struct {
+	Len int
+	// Revision changes whenever a field is revealed or masked, so the
+	// fields get fetched again
+	Revision int
+
+	mu       sync.Mutex
+	name     string
+	metadata *Secret
+	fields   []field
+	expire   *time.Timer
+}
+
+// Get gets the field at a specific index, with its value masked when hidden
+func (r *Revealed) Get(index int) Field {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if index >= len(r.fields) {
+		return Field{}
+	}
+	f := r.fields[index]
+	if f.hidden {
+		return Field{Key: string(f.key), Value: mask, Secret: f.secret, Hidden: true}
+	}
+	return Field{Key: string(f.key), Value: string(f.value), Secret: f.secret}
+}
+
+// Toggle reveals or masks the secret field at a specific index
+func (r *Revealed) Toggle(index int) {
+	ui.idle.activity()
+	r.mu.Lock()
+	if index < len(r.fields) && r.fields[index].secret {
+		r.fields[index].hidden = !r.fields[index].hidden
+		r.Revision++
+	}
+	r.mu.Unlock()
+	qml.Changed(r, &r.Revision)
+}
+
+// ToggleAll reveals all secret fields, or masks them again when none of
+// them are hidden
+func (r *Revealed) ToggleAll() {
+	ui.idle.activity()
+	r.mu.Lock()
+	hidden := false
+	for _, f := range r.fields {
+		hidden = hidden || f.hidden
+	}
+	for i := range r.fields {
+		if r.fields[i].secret {
+			r.fields[i].hidden = !hidden
+		}
+	}
+	r.Revision++
+	r.mu.Unlock()
+	qml.Changed(r, &r.Revision)
+}
+
+// set replaces the revealed metadata, which expires after timeout.
+// The old metadata is wiped.
+func (r *Revealed) set(name string, metadata *Secret, timeout time.Duration, expired func()) {
+	r.mu.Lock()
+	if r.expire != nil {
+		r.expire.Stop()
+		r.expire = nil
+	}
+	r.metadata.Wipe()
+	r.name = name
+	r.metadata = metadata
+	r.fields = parseFields(metadata.Bytes())
+	r.Len = len(r.fields)
+	r.Revision++
+	if name != "" && timeout > 0 {
+		r.expire = time.AfterFunc(timeout, expired)
+	}
+	r.mu.Unlock()
+	qml.Changed(r, &r.Len)
+	qml.Changed(r, &r.Revision)
+}
+
+// showing returns the name of the password that is revealed
+func (r *Revealed) showing() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.name
+}
+
+// Reveal decrypts the metadata of the selected password and shows it
+func (ui *UI) Reveal() {
+	ui.idle.activity()
+	p := &passwords
+	pw, ok := p.selected()
+	if !ok {
+		ui.setStatus("No password selected")
+		return
+	}
+	ui.decrypt(pw, pw.Metadata, func(metadata *Secret) {
+		revealed.set(pw.Name, metadata, revealTimeout(), func() {
+			ui.hideMetadata("Metadata hidden again")
+		})
+		ui.ShowMetadata = true
+		qml.Changed(ui, &ui.ShowMetadata)
+		// Decrypting probably unlocked the key
+		p.Update("")
+	})
+}
+
+// hideMetadata forgets the revealed metadata
+func (ui *UI) hideMetadata(status string) {
+	revealed.set("", nil, 0, nil)
+	ui.ShowMetadata = false
+	qml.Changed(ui, &ui.ShowMetadata)
+	if status != "" {
+		ui.setStatus(status)
+	}
+}
+
+var revealed Revealed
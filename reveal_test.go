@@ -0,0 +1,115 @@
+package main
+
+import "testing"
+
+const testMetadata = `login: alice
+password: hunter2
+PIN: 1234
+otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP
+url: https://example.com
+just a note
+`
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		key, value string
+		secret     bool
+	}{
+		{"login", "alice", false},
+		{"password", "hunter2", true},
+		{"PIN", "1234", true},
+		{"", "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP", true},
+		{"url", "https://example.com", false},
+		{"", "just a note", false},
+	}
+	fields := parseFields([]byte(testMetadata))
+	if len(fields) != len(tests) {
+		t.Fatalf("got %d fields, want %d", len(fields), len(tests))
+	}
+	for i, test := range tests {
+		f := fields[i]
+		if string(f.key) != test.key || string(f.value) != test.value {
+			t.Errorf("field %d is %q: %q, want %q: %q", i, f.key, f.value, test.key, test.value)
+		}
+		if f.secret != test.secret || f.hidden != test.secret {
+			t.Errorf("field %q: secret %v, hidden %v, want both %v", f.key, f.secret, f.hidden, test.secret)
+		}
+	}
+
+	for _, empty := range []string{"", "\n", "  \n"} {
+		if fields := parseFields([]byte(empty)); fields != nil {
+			t.Errorf("parseFields(%q) = %v, want no fields", empty, fields)
+		}
+	}
+}
+
+func TestRevealedToggle(t *testing.T) {
+	var r Revealed
+	r.set("test", secretFrom([]byte(testMetadata)), 0, nil)
+	defer r.set("", nil, 0, nil)
+	if r.Len != 6 {
+		t.Fatalf("Len is %d, want 6", r.Len)
+	}
+
+	if f := r.Get(1); !f.Hidden || f.Value != mask {
+		t.Errorf("password starts as %+v, want it masked", f)
+	}
+	if f := r.Get(0); f.Hidden || f.Value != "alice" {
+		t.Errorf("login starts as %+v, want it shown", f)
+	}
+
+	revision := r.Revision
+	r.Toggle(1)
+	if f := r.Get(1); f.Hidden || f.Value != "hunter2" {
+		t.Errorf("password after toggling is %+v, want it shown", f)
+	}
+	if f := r.Get(2); !f.Hidden {
+		t.Errorf("toggling the password revealed the PIN")
+	}
+	if r.Revision == revision {
+		t.Error("revision didn't change when revealing a field")
+	}
+
+	// Fields that aren't secret can't be masked
+	revision = r.Revision
+	r.Toggle(0)
+	if f := r.Get(0); f.Hidden {
+		t.Error("login was masked")
+	}
+	if r.Revision != revision {
+		t.Error("revision changed when nothing did")
+	}
+	r.Toggle(100)
+
+	r.Toggle(1)
+	if f := r.Get(1); !f.Hidden {
+		t.Error("password not masked after toggling twice")
+	}
+}
+
+func TestRevealedToggleAll(t *testing.T) {
+	var r Revealed
+	r.set("test", secretFrom([]byte(testMetadata)), 0, nil)
+	defer r.set("", nil, 0, nil)
+
+	// With some fields hidden, everything is revealed
+	r.Toggle(1)
+	revision := r.Revision
+	r.ToggleAll()
+	for i := 0; i < r.Len; i++ {
+		if f := r.Get(i); f.Hidden {
+			t.Errorf("field %d still hidden after revealing all", i)
+		}
+	}
+	if r.Revision == revision {
+		t.Error("revision didn't change when revealing all fields")
+	}
+
+	// With nothing hidden, the secret fields are masked again
+	r.ToggleAll()
+	for i := 0; i < r.Len; i++ {
+		if f := r.Get(i); f.Hidden != f.Secret {
+			t.Errorf("field %d is %+v after masking all", i, f)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"io"
+)
+
+// Secret holds decrypted data. It is kept out of swap where the OS allows
+// it, and should be wiped as soon as it is no longer needed. Converting it
+// to a string makes a copy that can't be wiped, so only do that when
+// handing it to something that needs a string.
+type Secret struct {
+	buf  []byte
+	n    int
+	free func([]byte)
+}
+
+func newSecret(size int) *Secret {
+	if size < 64 {
+		size = 64
+	}
+	buf, free := allocSecret(size)
+	return &Secret{buf: buf, free: free}
+}
+
+// secretFrom copies b into a new Secret
+func secretFrom(b []byte) *Secret {
+	s := newSecret(len(b))
+	s.n = copy(s.buf, b)
+	return s
+}
+
+// readSecret reads everything from r into a new Secret
+func readSecret(r io.Reader) (*Secret, error) {
+	s := newSecret(4096)
+	for {
+		if s.n == len(s.buf) {
+			s.grow()
+		}
+		n, err := r.Read(s.buf[s.n:])
+		s.n += n
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			s.Wipe()
+			return nil, err
+		}
+	}
+}
+
+// Write appends to the secret, so it can be written to like a buffer
+func (s *Secret) Write(p []byte) (int, error) {
+	if s.buf == nil {
+		s.buf, s.free = allocSecret(4096)
+	}
+	for s.n+len(p) > len(s.buf) {
+		s.grow()
+	}
+	copy(s.buf[s.n:], p)
+	s.n += len(p)
+	return len(p), nil
+}
+
+// grow doubles the size of the buffer, wiping the old one
+func (s *Secret) grow() {
+	buf, free := allocSecret(2 * len(s.buf))
+	copy(buf, s.buf[:s.n])
+	s.wipeBuf()
+	s.buf, s.free = buf, free
+}
+
+// Bytes returns the secret, which is only valid until it is wiped
+func (s *Secret) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.buf[:s.n]
+}
+
+// String copies the secret into a string
+func (s *Secret) String() string {
+	return string(s.Bytes())
+}
+
+// Len is the length of the secret
+func (s *Secret) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.n
+}
+
+// Line returns a copy of the first line of the secret, with its newline
+func (s *Secret) Line() *Secret {
+	b := s.Bytes()
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i+1]
+	}
+	return secretFrom(b)
+}
+
+// Rest returns a copy of everything after the first line of the secret,
+// up to an end of text character
+func (s *Secret) Rest() *Secret {
+	b := s.Bytes()
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return newSecret(0)
+	}
+	b = b[i+1:]
+	if i := bytes.IndexByte(b, '\003'); i >= 0 {
+		b = b[:i]
+	}
+	return secretFrom(b)
+}
+
+// Wipe overwrites the secret and releases its memory
+func (s *Secret) Wipe() {
+	if s == nil || s.buf == nil {
+		return
+	}
+	s.wipeBuf()
+	s.buf = nil
+	s.n = 0
+}
+
+func (s *Secret) wipeBuf() {
+	wipe(s.buf)
+	if s.free != nil {
+		s.free(s.buf)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"syscall"
+)
+
+// allocSecret allocates memory outside the Go heap and locks it so it
+// doesn't get swapped out. When locking fails, for example because
+// RLIMIT_MEMLOCK is reached, the memory is used anyway.
+func allocSecret(size int) ([]byte, func([]byte)) {
+	buf, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, size), nil
+	}
+	syscall.Mlock(buf)
+	return buf, func(b []byte) {
+		syscall.Munlock(b)
+		syscall.Munmap(b)
+	}
+}
+
+// disableCoreDumps keeps decrypted passwords from ending up on disk in a
+// core dump, and keeps other processes from reading our memory with ptrace
+func disableCoreDumps() error {
+	if err := syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{}); err != nil {
+		return err
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_DUMPABLE, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+// allocSecret allocates normal memory, since locking it isn't supported here
+func allocSecret(size int) ([]byte, func([]byte)) {
+	return make([]byte, size), nil
+}
+
+// disableCoreDumps isn't supported here
+func disableCoreDumps() error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestSecretLineRest(t *testing.T) {
+	tests := []struct {
+		name, decrypted, line, rest string
+	}{
+		{"empty", "", "", ""},
+		{"no newline", "hunter2", "hunter2", ""},
+		{"only a password", "hunter2\n", "hunter2\n", ""},
+		{"empty body", "hunter2\n\n", "hunter2\n", "\n"},
+		{"metadata", "hunter2\nlogin: alice\nurl: example.com\n", "hunter2\n", "login: alice\nurl: example.com\n"},
+		{"CRLF", "hunter2\r\nlogin: alice\r\n", "hunter2\r\n", "login: alice\r\n"},
+		{"empty password", "\nlogin: alice\n", "\n", "login: alice\n"},
+		{"end of text", "hunter2\nlogin: alice\n\003padding", "hunter2\n", "login: alice\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := secretFrom([]byte(test.decrypted))
+			defer s.Wipe()
+			line, rest := s.Line(), s.Rest()
+			defer line.Wipe()
+			defer rest.Wipe()
+			if line.String() != test.line {
+				t.Errorf("Line() = %q, want %q", line, test.line)
+			}
+			if rest.String() != test.rest {
+				t.Errorf("Rest() = %q, want %q", rest, test.rest)
+			}
+			// Both are copies, so they outlive the secret
+			if s.String() != test.decrypted {
+				t.Errorf("secret changed to %q", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are passwords, and words passwords are made from, that
+// are among the first to be guessed
+var commonPasswords = []string{
+	"123456", "password", "qwerty", "abc123", "letmein", "monkey", "dragon",
+	"111111", "baseball", "iloveyou", "trustno1", "sunshine", "master",
+	"welcome", "shadow", "ashley", "football", "jesus", "michael", "ninja",
+	"mustang", "superman", "batman", "princess", "starwars", "whatever",
+	"freedom", "hello", "charlie", "secret", "summer", "winter", "spring",
+	"autumn", "love", "admin", "login", "passw0rd", "access", "flower",
+	"hottie", "loveme", "zaq1zaq1", "qazwsx", "michelle", "jordan", "hunter",
+	"killer", "soccer", "hockey", "ranger", "buster", "thomas", "tigger",
+	"robert", "daniel", "andrew", "joshua", "pepper", "ginger", "cheese",
+	"computer", "internet", "changeme", "default", "guest", "test", "root",
+	"pass", "god", "money", "orange", "banana", "apple", "chocolate",
+	"matrix", "maggie", "cookie", "silver", "golden", "lovely", "angel",
+	"family", "friends", "forever", "purple", "yellow", "blue", "red",
+	"green", "black", "white", "company", "office", "work", "home",
+}
+
+// keyboardRows are runs of keys people like to type as passwords
+var keyboardRows = []string{
+	"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890", "qwertzuiop", "azertyuiop",
+}
+
+// leet undoes the usual letter substitutions
+var leet = strings.NewReplacer("0", "o", "1", "l", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+// Strength is an estimate of how hard a password is to guess
+type Strength struct {
+	// Score goes from 0 (guessed right away) to 4 (very hard to guess),
+	// like zxcvbn's
+	Score int
+	// Log10Guesses is roughly how many guesses it takes, as a power of 10
+	Log10Guesses float64
+}
+
+// estimateStrength estimates how many guesses a password takes, in the
+// spirit of zxcvbn: it looks for common passwords, repeated characters,
+// sequences, keyboard runs and years, and only counts the rest as random.
+func estimateStrength(password []byte) Strength {
+	if len(password) == 0 {
+		return Strength{}
+	}
+	if isCommon(string(password)) {
+		return Strength{Score: 0, Log10Guesses: 2}
+	}
+	var bits float64
+	runes := []rune(string(password))
+	for i := 0; i < len(runes); {
+		n, b := matchPattern(runes[i:])
+		bits += b
+		i += n
+	}
+	guesses := bits * math.Log10(2)
+	return Strength{Score: score(guesses), Log10Guesses: guesses}
+}
+
+// score turns guesses into a score with zxcvbn's thresholds
+func score(guesses float64) int {
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	}
+	return 4
+}
+
+// isCommon tells if the password is a common one, possibly with some
+// letters substituted and digits or symbols added at the end
+func isCommon(password string) bool {
+	p := strings.ToLower(password)
+	base := strings.TrimRightFunc(p, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	for _, c := range commonPasswords {
+		if p == c || base == c || leet.Replace(p) == c || leet.Replace(base) == c {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern finds the pattern at the start of s, returning how many
+// runes it covers and how many bits of entropy it has
+func matchPattern(s []rune) (int, float64) {
+	if n := repeatLen(s); n >= 3 {
+		return n, math.Log2(charPool(s[0])) + math.Log2(float64(n))
+	}
+	if n := sequenceLen(s); n >= 3 {
+		return n, math.Log2(charPool(s[0])) + 1 + math.Log2(float64(n))
+	}
+	if n := keyboardLen(s); n >= 4 {
+		return n, math.Log2(float64(len(keyboardRows)*10)) + math.Log2(float64(n))
+	}
+	if isYear(s) {
+		return 4, math.Log2(200)
+	}
+	return 1, math.Log2(charPool(s[0]))
+}
+
+func repeatLen(s []rune) int {
+	n := 1
+	for n < len(s) && s[n] == s[0] {
+		n++
+	}
+	return n
+}
+
+// sequenceLen finds runs like abc, 987 or xyz
+func sequenceLen(s []rune) int {
+	if len(s) < 2 {
+		return 1
+	}
+	delta := s[1] - s[0]
+	if delta != 1 && delta != -1 {
+		return 1
+	}
+	n := 2
+	for n < len(s) && s[n]-s[n-1] == delta {
+		n++
+	}
+	return n
+}
+
+// keyboardLen finds runs of keys next to each other, like qwerty
+func keyboardLen(s []rune) int {
+	lower := strings.ToLower(string(s))
+	best := 0
+	for _, row := range keyboardRows {
+		for n := len(row); n > best; n-- {
+			if n <= len(lower) && strings.Contains(row, lower[:n]) {
+				best = n
+				break
+			}
+		}
+	}
+	return best
+}
+
+func isYear(s []rune) bool {
+	if len(s) < 4 {
+		return false
+	}
+	y := string(s[:4])
+	for _, r := range y {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return strings.HasPrefix(y, "19") || strings.HasPrefix(y, "20")
+}
+
+// charPool is the number of characters of the same kind as r
+func charPool(r rune) float64 {
+	switch {
+	case unicode.IsLower(r):
+		return 26
+	case unicode.IsUpper(r):
+		return 26
+	case unicode.IsDigit(r):
+		return 10
+	case r < unicode.MaxASCII:
+		return 33
+	}
+	return 100
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestEstimateStrength(t *testing.T) {
+	for _, tc := range []struct {
+		password string
+		score    int
+	}{
+		{"", 0},
+		// Common passwords, also with substitutions and suffixes
+		{"password", 0},
+		{"Passw0rd!", 0},
+		{"qwerty123", 0},
+		// Patterns that look long but aren't
+		{"aaaaaa", 0},
+		{"19841984", 1},
+		{"abcdefghij", 0},
+		{"asdfghjkl", 0},
+		// Random looking passwords
+		{"kT9#xQ2v", 4},
+		{"X9#mQ2$vL7@pR4!z", 4},
+		{"correct horse battery staple", 4},
+	} {
+		if got := estimateStrength([]byte(tc.password)); got.Score != tc.score {
+			t.Errorf("%q has score %d (%.1f), want %d", tc.password, got.Score, got.Log10Guesses, tc.score)
+		}
+	}
+}
+
+func TestIsCommon(t *testing.T) {
+	for password, want := range map[string]bool{
+		"password":   true,
+		"PASSWORD":   true,
+		"p@ssw0rd":   true,
+		"Passw0rd!":  true,
+		"qwerty123":  true,
+		"dragon2024": true,
+		"m0nk3y":     true,
+		"passwords":  false,
+		"dragonfly":  false,
+		"kT9#xQ2v":   false,
+		"":           false,
+	} {
+		if got := isCommon(password); got != want {
+			t.Errorf("isCommon(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/limetext/qml-go"
+)
+
+// tagsOf lists the tags in the indexed metadata of a password, which are
+// written like "tags: work, shared"
+func tagsOf(fields map[string]string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	split := func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }
+	for _, tag := range strings.FieldsFunc(strings.ToLower(fields["tags"]), split) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Tag is a tag used in the store, and how many passwords have it
+type Tag struct {
+	Name  string
+	Count int
+}
+
+// Tags lists the tags of all passwords, sorted by name. Tags are only
+// known for indexed passwords.
+func (ps *PasswordStore) Tags() []Tag {
+	counts := make(map[string]int)
+	for _, p := range ps.passwords {
+		for _, tag := range tagsOf(ps.metadata(p)) {
+			counts[tag]++
+		}
+	}
+	var tags []Tag
+	for name, count := range counts {
+		tags = append(tags, Tag{name, count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+// Facets is the model for the tags in the sidebar
+type Facets struct {
+	Len int
+	// Revision changes whenever the tags change, so they get fetched again
+	Revision int
+	tags     []Tag
+}
+
+// Get gets the tag at a specific index
+func (f *Facets) Get(index int) Tag {
+	if index < 0 || index >= len(f.tags) {
+		return Tag{}
+	}
+	return f.tags[index]
+}
+
+func (f *Facets) update(tags []Tag) {
+	if len(tags) == len(f.tags) {
+		same := true
+		for i := range tags {
+			same = same && tags[i] == f.tags[i]
+		}
+		if same {
+			return
+		}
+	}
+	f.tags = tags
+	f.Len = len(tags)
+	f.Revision++
+	qml.Changed(f, &f.Len)
+	qml.Changed(f, &f.Revision)
+}
+
+var facets Facets
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagsOf(t *testing.T) {
+	for tags, want := range map[string][]string{
+		"":                       nil,
+		"work":                   {"work"},
+		"work, shared":           {"work", "shared"},
+		"Work,shared\tWORK  two": {"work", "shared", "two"},
+		" , ,":                   nil,
+	} {
+		if got := tagsOf(map[string]string{"tags": tags}); !reflect.DeepEqual(got, want) {
+			t.Errorf("tagsOf(%q) = %q, want %q", tags, got, want)
+		}
+	}
+	if got := tagsOf(nil); got != nil {
+		t.Errorf("tags without metadata: %q", got)
+	}
+}
+
+func TestTags(t *testing.T) {
+	store := &PasswordStore{
+		passwords: []Password{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		index: mapIndex{
+			"a": {"tags": "work, shared"},
+			"b": {"tags": "Work"},
+		},
+	}
+	want := []Tag{{"shared", 1}, {"work", 2}}
+	if got := store.Tags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/limetext/qml-go"
+)
+
+// Theme is the colors of the UI
+type Theme struct {
+	// Background is the color of the window and of text fields
+	Background string
+	Border     string
+	// Pane is the color of the right pane and of the selected entry
+	Pane string
+	Box  string
+	Text string
+	// Strong is the color of text that is typed or decrypted
+	Strong string
+	// Dim is the color of labels and the status line
+	Dim         string
+	Entry       string
+	Folder      string
+	Faint       string
+	Placeholder string
+	Control     string
+	Button      string
+	// Accent is the color of what is selected
+	Accent   string
+	Progress string
+	Warning  string
+	// Overlay is the color of dialogs shown over the window
+	Overlay string
+	// Match and MatchFolder highlight what a query matched
+	Match       string
+	MatchFolder string
+}
+
+// themes are the themes GoPass comes with
+var themes = map[string]Theme{
+	"dark": {
+		Background:  "#333",
+		Border:      "#aaa",
+		Pane:        "#444",
+		Box:         "#555",
+		Text:        "#eee",
+		Strong:      "white",
+		Dim:         "#aaa",
+		Entry:       "gray",
+		Folder:      "#bbb",
+		Faint:       "#888",
+		Placeholder: "#444",
+		Control:     "#666",
+		Button:      "#999",
+		Accent:      "#dd00bb",
+		Progress:    "#a6a",
+		Warning:     "#966",
+		Overlay:     "#e0333333",
+		Match:       "#eee",
+		MatchFolder: "#c8c",
+	},
+	"light": {
+		Background:  "#f4f4f4",
+		Border:      "#888",
+		Pane:        "#e2e2e2",
+		Box:         "#d4d4d4",
+		Text:        "#222",
+		Strong:      "black",
+		Dim:         "#555",
+		Entry:       "#777",
+		Folder:      "#444",
+		Faint:       "#888",
+		Placeholder: "#bbb",
+		Control:     "#aaa",
+		Button:      "#666",
+		Accent:      "#a0008c",
+		Progress:    "#a6a",
+		Warning:     "#c66",
+		Overlay:     "#e0f4f4f4",
+		Match:       "black",
+		MatchFolder: "#939",
+	},
+}
+
+// colorNames are the color names QML knows, from SVG
+var colorNames = strings.Fields(`
+	aliceblue antiquewhite aqua aquamarine azure beige bisque black
+	blanchedalmond blue blueviolet brown burlywood cadetblue chartreuse
+	chocolate coral cornflowerblue cornsilk crimson cyan darkblue
+	darkcyan darkgoldenrod darkgray darkgreen darkgrey darkkhaki
+	darkmagenta darkolivegreen darkorange darkorchid darkred darksalmon
+	darkseagreen darkslateblue darkslategray darkslategrey darkturquoise
+	darkviolet deeppink deepskyblue dimgray dimgrey dodgerblue firebrick
+	floralwhite forestgreen fuchsia gainsboro ghostwhite gold goldenrod
+	gray grey green greenyellow honeydew hotpink indianred indigo ivory
+	khaki lavender lavenderblush lawngreen lemonchiffon lightblue
+	lightcoral lightcyan lightgoldenrodyellow lightgray lightgreen
+	lightgrey lightpink lightsalmon lightseagreen lightskyblue
+	lightslategray lightslategrey lightsteelblue lightyellow lime
+	limegreen linen magenta maroon mediumaquamarine mediumblue
+	mediumorchid mediumpurple mediumseagreen mediumslateblue
+	mediumspringgreen mediumturquoise mediumvioletred midnightblue
+	mintcream mistyrose moccasin navajowhite navy oldlace olive olivedrab
+	orange orangered orchid palegoldenrod palegreen paleturquoise
+	palevioletred papayawhip peachpuff peru pink plum powderblue purple
+	red rosybrown royalblue saddlebrown salmon sandybrown seagreen
+	seashell sienna silver skyblue slateblue slategray slategrey snow
+	springgreen steelblue tan teal thistle tomato turquoise violet wheat
+	white whitesmoke yellow yellowgreen transparent`)
+
+// validColor tells if QML understands a color, which is either a name or
+// written like #rgb, #rrggbb or #aarrggbb
+func validColor(color string) bool {
+	if hex := strings.TrimPrefix(color, "#"); hex != color {
+		switch len(hex) {
+		case 3, 6, 8, 9, 12:
+		default:
+			return false
+		}
+		for _, c := range strings.ToLower(hex) {
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, name := range colorNames {
+		if strings.EqualFold(color, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// colors gives the colors of the theme by their lowercase name, as they
+// are written in theme files
+func (t *Theme) colors() map[string]*string {
+	return map[string]*string{
+		"background":  &t.Background,
+		"border":      &t.Border,
+		"pane":        &t.Pane,
+		"box":         &t.Box,
+		"text":        &t.Text,
+		"strong":      &t.Strong,
+		"dim":         &t.Dim,
+		"entry":       &t.Entry,
+		"folder":      &t.Folder,
+		"faint":       &t.Faint,
+		"placeholder": &t.Placeholder,
+		"control":     &t.Control,
+		"button":      &t.Button,
+		"accent":      &t.Accent,
+		"progress":    &t.Progress,
+		"warning":     &t.Warning,
+		"overlay":     &t.Overlay,
+		"match":       &t.Match,
+		"matchfolder": &t.MatchFolder,
+	}
+}
+
+// themeName is the theme to use, set with GOPASS_THEME. It is dark, light,
+// system to follow the desktop, or the name of a theme file.
+func themeName() string {
+	if name := os.Getenv("GOPASS_THEME"); name != "" {
+		return name
+	}
+	return "system"
+}
+
+// themeFile is where the theme with the given name is read from
+func themeFile(name string) string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir(), ".config")
+	}
+	return filepath.Join(dir, "gopass", "themes", name+".theme")
+}
+
+// loadTheme finds the theme with the given name. Theme files have a line
+// for each color that's different from the theme they're based on, which
+// is the one for the desktop unless there is a line like "base: light".
+func loadTheme(name string, dark bool) (Theme, error) {
+	system := "light"
+	if dark {
+		system = "dark"
+	}
+	if name == "system" {
+		name = system
+	}
+	if t, ok := themes[name]; ok {
+		return t, nil
+	}
+
+	path := themeFile(name)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return themes[system], fmt.Errorf("Unknown theme %s, there is no %s", name, path)
+	}
+	if err != nil {
+		return themes[system], err
+	}
+	defer f.Close()
+	type setting struct {
+		line       int
+		key, value string
+	}
+	var settings []setting
+	base := system
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return themes[system], fmt.Errorf("%s:%d: Expected name: color", path, n)
+		}
+		key, value := strings.ToLower(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1])
+		if key == "base" {
+			if _, ok := themes[value]; !ok && value != "system" {
+				return themes[system], fmt.Errorf("%s:%d: Unknown theme %s", path, n, value)
+			}
+			if value != "system" {
+				base = value
+			}
+			continue
+		}
+		settings = append(settings, setting{n, key, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return themes[system], err
+	}
+
+	t := themes[base]
+	colors := t.colors()
+	for _, s := range settings {
+		color, ok := colors[s.key]
+		if !ok {
+			return themes[system], fmt.Errorf("%s:%d: Unknown color %s", path, s.line, s.key)
+		}
+		if !validColor(s.value) {
+			return themes[system], fmt.Errorf("%s:%d: %s is not a color", path, s.line, s.value)
+		}
+		*color = s.value
+	}
+	return t, nil
+}
+
+// prefersDark asks the desktop if it prefers a dark appearance, through
+// the settings of the freedesktop portal. It's dark when nobody knows.
+func prefersDark() bool {
+	out, err := exec.Command("dbus-send", "--session", "--print-reply=literal",
+		"--dest=org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop",
+		"org.freedesktop.portal.Settings.Read",
+		"string:org.freedesktop.appearance", "string:color-scheme").Output()
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return true
+	}
+	// 1 is dark, 2 is light, and 0 is no preference
+	return fields[len(fields)-1] != "2"
+}
+
+// watchAppearance calls changed whenever the desktop switches between
+// light and dark, following the portal with dbus-monitor
+func watchAppearance(changed func(dark bool)) {
+	cmd := exec.Command("dbus-monitor", "--session",
+		"type='signal',interface='org.freedesktop.portal.Settings',member='SettingChanged'")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Not following the desktop appearance:", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "Not following the desktop appearance:", err)
+		return
+	}
+	// The signal has the namespace, key and value as arguments, each on a
+	// line of its own
+	var key string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "signal "):
+			key = ""
+		case strings.HasPrefix(line, "string "):
+			key = strings.Trim(strings.TrimPrefix(line, "string "), `"`)
+		case key == "color-scheme" && strings.Contains(line, "uint32 "):
+			fields := strings.Fields(line)
+			changed(fields[len(fields)-1] != "2")
+		}
+	}
+	cmd.Wait()
+}
+
+// setupTheme picks the theme, and follows the desktop's appearance unless
+// it's set to dark or light
+func setupTheme() error {
+	name := themeName()
+	t, err := loadTheme(name, prefersDark())
+	setTheme(t)
+	if name != "dark" && name != "light" {
+		go watchAppearance(func(dark bool) {
+			if t, err := loadTheme(name, dark); err == nil {
+				setTheme(t)
+				passwords.Update("")
+			}
+		})
+	}
+	return err
+}
+
+// setTheme changes the colors of the UI
+func setTheme(t Theme) {
+	theme = t
+	for _, color := range theme.colors() {
+		qml.Changed(&theme, color)
+	}
+}
+
+var theme Theme
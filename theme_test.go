@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTheme writes a theme file where loadTheme looks for it
+func writeTheme(t *testing.T, name, content string) {
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "gopass", "themes")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".theme"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	writeTheme(t, "teal", "# Teal accents\n\naccent: #0a8\nProgress: teal\n")
+	writeTheme(t, "teal-light", "base: light\naccent: #0a8\n")
+	writeTheme(t, "teal-system", "base: system\naccent: #800a8a8a\n")
+
+	withAccent := func(base, accent string) Theme {
+		th := themes[base]
+		th.Accent = accent
+		return th
+	}
+	tealDark := withAccent("dark", "#0a8")
+	tealDark.Progress = "teal"
+	tealLight := withAccent("light", "#0a8")
+	tealLight.Progress = "teal"
+
+	for _, tc := range []struct {
+		name string
+		dark bool
+		want Theme
+	}{
+		{"dark", false, themes["dark"]},
+		{"light", true, themes["light"]},
+		{"system", true, themes["dark"]},
+		{"system", false, themes["light"]},
+		// Theme files are based on the desktop's theme
+		{"teal", true, tealDark},
+		{"teal", false, tealLight},
+		// Unless they say otherwise
+		{"teal-light", true, withAccent("light", "#0a8")},
+		{"teal-system", false, withAccent("light", "#800a8a8a")},
+	} {
+		got, err := loadTheme(tc.name, tc.dark)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s with dark %v is %+v, want %+v", tc.name, tc.dark, got, tc.want)
+		}
+	}
+}
+
+func TestLoadThemeErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for name, tc := range map[string]struct {
+		content, err string
+	}{
+		"missing":       {"", "Unknown theme missing"},
+		"unknown-color": {"accent: #0a8\nhighlight: red\n", "unknown-color.theme:2: Unknown color highlight"},
+		"no-colon":      {"# Comment\naccent #0a8\n", "no-colon.theme:2: Expected name: color"},
+		"no-value":      {"accent:\n", "no-value.theme:1: Expected name: color"},
+		"unknown-base":  {"base: solarized\n", "unknown-base.theme:1: Unknown theme solarized"},
+		"short-hex":     {"accent: #0a\n", "short-hex.theme:1: #0a is not a color"},
+		"bad-hex":       {"text: #eeg\n", "bad-hex.theme:1: #eeg is not a color"},
+		"bad-name":      {"\n\naccent: bleu\n", "bad-name.theme:3: bleu is not a color"},
+	} {
+		if name != "missing" {
+			writeTheme(t, name, tc.content)
+		}
+		// The desktop's theme is used when the file can't be
+		got, err := loadTheme(name, false)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v, want %q", name, err, tc.err)
+		}
+		if got != themes["light"] {
+			t.Errorf("%s: got %+v, want the light theme", name, got)
+		}
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for color, want := range map[string]bool{
+		"#abc":          true,
+		"#AABBCC":       true,
+		"#e0333333":     true,
+		"white":         true,
+		"DarkSlateGray": true,
+		"transparent":   true,
+		"":              false,
+		"#":             false,
+		"#abcd":         false,
+		"#abcdefg":      false,
+		"bleu":          false,
+		"rgb(1,2,3)":    false,
+	} {
+		if got := validColor(color); got != want {
+			t.Errorf("validColor(%q) = %v, want %v", color, got, want)
+		}
+	}
+	// The colors GoPass comes with are all fine
+	for name, th := range themes {
+		for key, color := range th.colors() {
+			if !validColor(*color) {
+				t.Errorf("%s theme has %s %q", name, key, *color)
+			}
+		}
+	}
+}
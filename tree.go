@@ -0,0 +1,163 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/limetext/qml-go"
+)
+
+// Folder is a directory in the password store
+type Folder struct {
+	Name string
+	// Path is relative to the store, "" for the store itself
+	Path      string
+	Folders   []*Folder
+	Passwords []Password
+}
+
+// Tree arranges the passwords in folders, sorted by name
+func (ps *PasswordStore) Tree() *Folder {
+	root := &Folder{}
+	folders := map[string]*Folder{"": root}
+	var folderFor func(dir string) *Folder
+	folderFor = func(dir string) *Folder {
+		if f, ok := folders[dir]; ok {
+			return f
+		}
+		parent := folderFor(parentDir(dir))
+		f := &Folder{Name: path.Base(dir), Path: dir}
+		parent.Folders = append(parent.Folders, f)
+		folders[dir] = f
+		return f
+	}
+	for _, p := range ps.passwords {
+		f := folderFor(parentDir(p.Name))
+		f.Passwords = append(f.Passwords, p)
+	}
+	for _, f := range folders {
+		sort.Slice(f.Folders, func(i, j int) bool { return f.Folders[i].Name < f.Folders[j].Name })
+		sort.Slice(f.Passwords, func(i, j int) bool { return f.Passwords[i].Name < f.Passwords[j].Name })
+	}
+	return root
+}
+
+// Find finds the folder with the given path below f
+func (f *Folder) Find(p string) *Folder {
+	if p == "" {
+		return f
+	}
+	for _, sub := range f.Folders {
+		if sub.Path == p || strings.HasPrefix(p, sub.Path+"/") {
+			return sub.Find(p)
+		}
+	}
+	return nil
+}
+
+// parentDir is the folder a password or folder is in, "" at the top
+func parentDir(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// Entry is a row in the password list, either a folder or a password
+type Entry struct {
+	// Name is the name relative to the row's parent, Path is the full
+	// name in the store, and Display is the name elided and highlighted
+	Name    string
+	Path    string
+	Display string
+	// Reason tells where the query matched
+	Reason    string
+	Depth     int
+	IsFolder  bool
+	Expanded  bool
+	Favourite bool
+	pw        Password
+}
+
+// treeRows lists the contents of f, and of its expanded folders below it
+func treeRows(f *Folder, depth int, expanded map[string]bool) []Entry {
+	var rows []Entry
+	for _, sub := range f.Folders {
+		open := expanded[sub.Path]
+		rows = append(rows, Entry{Name: sub.Name, Path: sub.Path, Display: displayName(sub.Name, nil, 0, maxDisplayLen),
+			Depth: depth, IsFolder: true, Expanded: open})
+		if open {
+			rows = append(rows, treeRows(sub, depth+1, expanded)...)
+		}
+	}
+	for _, p := range f.Passwords {
+		name := path.Base(p.Name)
+		rows = append(rows, Entry{Name: name, Path: p.Name, Display: displayName(name, nil, 0, maxDisplayLen), Depth: depth, pw: p})
+	}
+	return rows
+}
+
+// searchRows lists the passwords in folder that match the query
+func searchRows(hits []Hit, folder string) []Entry {
+	var rows []Entry
+	for _, h := range hits {
+		name, offset := h.Name, 0
+		if folder != "" {
+			if !strings.HasPrefix(h.Name, folder+"/") {
+				continue
+			}
+			offset = len(folder) + 1
+			name = h.Name[offset:]
+		}
+		rows = append(rows, Entry{
+			Name:    name,
+			Path:    h.Name,
+			Display: displayName(name, h.Spans, offset, maxDisplayLen),
+			Reason:  h.Reason(),
+			pw:      h.Password,
+		})
+	}
+	return rows
+}
+
+// selected returns the selected password, if a password is selected
+func (p *Passwords) selected() (Password, bool) {
+	if p.Selected < 0 || p.Selected >= len(p.rows) || p.rows[p.Selected].IsFolder {
+		return Password{}, false
+	}
+	return p.rows[p.Selected].pw, true
+}
+
+// Toggle expands or collapses the folder at a specific index
+func (p *Passwords) Toggle(index int) {
+	ui.idle.activity()
+	if index < 0 || index >= len(p.rows) || !p.rows[index].IsFolder {
+		return
+	}
+	f := p.rows[index].Path
+	p.expanded[f] = !p.expanded[f]
+	p.Update("")
+}
+
+// Enter shows only the given folder, "" for the whole store
+func (p *Passwords) Enter(folder string) {
+	ui.idle.activity()
+	p.Folder = folder
+	qml.Changed(p, &p.Folder)
+	p.Update("")
+}
+
+// EnterSelected shows only the selected folder
+func (p *Passwords) EnterSelected() {
+	if p.Selected >= 0 && p.Selected < len(p.rows) && p.rows[p.Selected].IsFolder {
+		p.Enter(p.rows[p.Selected].Path)
+	}
+}
+
+// Up goes to the folder above the one shown
+func (p *Passwords) Up() {
+	if p.Folder != "" {
+		p.Enter(parentDir(p.Folder))
+	}
+}